@@ -0,0 +1,341 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// auditShown is how many changes the audit command lists
+const auditShown = 10
+
+// Audit actions
+const (
+	auditOverride = "override"
+	auditDelete   = "delete"
+	auditBackfill = "backfill"
+)
+
+// AuditEntry is a change an admin made to someone's result. Scores are nil
+// when there was no result before, or isn't one after.
+type AuditEntry struct {
+	team      string
+	channel   string
+	adminId   string
+	action    string
+	game      string
+	wordlenum int
+	userId    string
+	oldScore  *int
+	newScore  *int
+	changedAt time.Time
+}
+
+// isAdmin is true for the users in ADMINS, the channel's admins, and the
+// platform's own admins
+func (h *HTTPHandler) isAdmin(sc scope, userId string) (bool, error) {
+	if h.config != nil && slices.Contains(h.config.Admins, userId) {
+		return true, nil
+	}
+	admin, err := h.chat.IsAdmin(sc.channel, userId)
+	if err != nil || admin {
+		return admin, err
+	}
+	admins, err := h.db.getChannelAdmins(sc)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(admins, userId), nil
+}
+
+// parsePuzzle reads a puzzle number, like 1283, 1,283 or #1283
+func parsePuzzle(arg string) (int, error) {
+	num, err := strconv.Atoi(strings.ReplaceAll(strings.TrimPrefix(arg, "#"), ",", ""))
+	if err != nil || num <= 0 {
+		return 0, fmt.Errorf("%q isn't a puzzle number", arg)
+	}
+	return num, nil
+}
+
+// parseScore reads a score for game as it's shared, like 3, 3/6, X, failed
+// or 1:23 for the Mini
+func parseScore(game Game, arg string) (int, error) {
+	arg = strings.ToLower(arg)
+	if arg == "x" || arg == "failed" || strings.HasPrefix(arg, "x/") {
+		for score := 0; score < 1000; score++ {
+			if game.ValidScore(score) && !game.Solved(score) {
+				return score, nil
+			}
+		}
+		return 0, fmt.Errorf("You can't fail %s", game.Name())
+	}
+	if minutes, seconds, ok := strings.Cut(arg, ":"); ok {
+		m, errM := strconv.Atoi(minutes)
+		s, errS := strconv.Atoi(seconds)
+		if errM == nil && errS == nil && m >= 0 && s >= 0 && s < 60 {
+			if score := m*60 + s; game.ValidScore(score) {
+				return score, nil
+			}
+		}
+	}
+	before, _, _ := strings.Cut(arg, "/")
+	score, err := strconv.Atoi(before)
+	if err != nil || !game.ValidScore(score) {
+		return 0, fmt.Errorf("%q isn't a %s score", arg, game.Name())
+	}
+	return score, nil
+}
+
+// playerResult returns a player's result for a puzzle, or nil
+func (h *HTTPHandler) playerResult(sc scope, game Game, wordlenum int, userId string) (*Result, error) {
+	dailies, err := h.db.getDailyResults(sc, game.Name(), wordlenum)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range dailies {
+		if r.userId == userId {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// handleOverride changes the score of an existing result
+func (h *HTTPHandler) handleOverride(sm ChatMessage, args commandArgs) error {
+	game := args.game()
+	userId := args.get("user")
+	wordlenum, err := parsePuzzle(args.get("puzzle"))
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	score, err := parseScore(game, args.get("score"))
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	res, err := h.playerResult(sm.scope(), game, wordlenum, userId)
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return h.chat.PostMessage(sm.channel, fmt.Sprintf("There's no %s result for <@%s>. Try %s backfill", game.PuzzleTitle(wordlenum), userId, botName))
+	}
+
+	oldScore := res.score
+	res.score = score
+	if err := h.db.putResult(*res); err != nil {
+		return err
+	}
+	return h.auditChange(sm, *res, auditOverride, &oldScore, &score)
+}
+
+// handleDelete removes a result
+func (h *HTTPHandler) handleDelete(sm ChatMessage, args commandArgs) error {
+	game := args.game()
+	userId := args.get("user")
+	wordlenum, err := parsePuzzle(args.get("puzzle"))
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	res, err := h.playerResult(sm.scope(), game, wordlenum, userId)
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return h.chat.PostMessage(sm.channel, fmt.Sprintf("There's no %s result for <@%s>", game.PuzzleTitle(wordlenum), userId))
+	}
+
+	if err := h.db.deleteResult(*res); err != nil {
+		return err
+	}
+	return h.auditChange(sm, *res, auditDelete, &res.score, nil)
+}
+
+// handleBackfill adds a result on someone's behalf. Unlike results people
+// share, it can be for any puzzle up to today's.
+func (h *HTTPHandler) handleBackfill(sm ChatMessage, args commandArgs) error {
+	game := args.game()
+	userId := args.get("user")
+	wordlenum, err := parsePuzzle(args.get("puzzle"))
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	score, err := parseScore(game, args.get("score"))
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	settings, err := h.db.getChannelSettings(sm.scope())
+	if err != nil {
+		return err
+	}
+	if today := settings.puzzleToday(game, h.now()); wordlenum > today {
+		return h.chat.PostMessage(sm.channel, fmt.Sprintf("%s isn't out yet. Today's is %s", game.PuzzleTitle(wordlenum), game.PuzzleTitle(today)))
+	}
+	existing, err := h.playerResult(sm.scope(), game, wordlenum, userId)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return h.chat.PostMessage(sm.channel, fmt.Sprintf("<@%s> already has %s for %s. Try %s override", userId,
+			game.ScoreLabel(existing.score), game.PuzzleTitle(wordlenum), botName))
+	}
+
+	name, err := h.chat.NameForUser(userId)
+	if err != nil {
+		return err
+	}
+	res := Result{
+		team:        sm.team,
+		channel:     sm.channel,
+		game:        game.Name(),
+		wordlenum:   wordlenum,
+		userId:      userId,
+		displayName: name,
+		score:       score,
+	}
+	if err := h.db.putResult(res); err != nil {
+		return err
+	}
+	return h.auditChange(sm, res, auditBackfill, nil, &score)
+}
+
+// auditChange records a change to res, redoes any ratings it affects,
+// refreshes the puzzle's summary and announces the change in a thread: the
+// result's own if it was posted as a message, or the summary's
+func (h *HTTPHandler) auditChange(sm ChatMessage, res Result, action string, oldScore, newScore *int) error {
+	entry := AuditEntry{
+		team:      sm.team,
+		channel:   sm.channel,
+		adminId:   sm.user,
+		action:    action,
+		game:      res.game,
+		wordlenum: res.wordlenum,
+		userId:    res.userId,
+		oldScore:  oldScore,
+		newScore:  newScore,
+		changedAt: h.now(),
+	}
+	if err := h.db.putAudit(entry); err != nil {
+		return err
+	}
+	if err := rerateFrom(h.db, res.scope(), gameFor(res), res.wordlenum); err != nil {
+		return err
+	}
+	if err := h.refreshLiveSummary(res.scope(), res.game, res.wordlenum); err != nil {
+		return err
+	}
+
+	msg, err := h.auditMessage(entry)
+	if err != nil {
+		return err
+	}
+	threadTs := res.ts
+	if threadTs == "" || strings.HasPrefix(threadTs, slashEventPrefix) {
+		if threadTs, err = h.db.getSummaryTs(res.scope(), res.game, res.wordlenum); err != nil {
+			return err
+		}
+	}
+	if threadTs == "" {
+		return h.chat.PostMessage(sm.channel, msg)
+	}
+	return h.chat.PostThreadReply(sm.channel, threadTs, msg)
+}
+
+// auditMessage describes a change, e.g. "sean changed lara's Wordle #1283
+// from 4/6 to 3/6"
+func (h *HTTPHandler) auditMessage(e AuditEntry) (string, error) {
+	admin, err := h.chat.NameForUser(e.adminId)
+	if err != nil {
+		return "", err
+	}
+	player, err := h.chat.NameForUser(e.userId)
+	if err != nil {
+		return "", err
+	}
+	game := gameByName(e.game)
+	if game == nil {
+		game = wordle{}
+	}
+	title := game.PuzzleTitle(e.wordlenum)
+	switch e.action {
+	case auditOverride:
+		return fmt.Sprintf(":memo: %s changed %s's %s from %s to %s", admin, player, title, game.ScoreLabel(*e.oldScore), game.ScoreLabel(*e.newScore)), nil
+	case auditDelete:
+		return fmt.Sprintf(":memo: %s deleted %s's %s (%s)", admin, player, title, game.ScoreLabel(*e.oldScore)), nil
+	default:
+		return fmt.Sprintf(":memo: %s added %s for %s (%s)", admin, title, player, game.ScoreLabel(*e.newScore)), nil
+	}
+}
+
+// handleAdmins lists the channel's admins, or lets an admin add or remove
+// one: admins [add|remove @user]
+func (h *HTTPHandler) handleAdmins(sm ChatMessage, args commandArgs) error {
+	change, userId := args.get("change"), args.get("user")
+	if change == "" && userId == "" {
+		admins, err := h.db.getChannelAdmins(sm.scope())
+		if err != nil {
+			return err
+		}
+		names := []string{}
+		for _, a := range admins {
+			name, err := h.chat.NameForUser(a)
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			return h.chat.PostMessage(sm.channel, "This channel has no admins of its own, just the workspace's")
+		}
+		return h.chat.PostMessage(sm.channel, "Admins of this channel: "+namesString(names))
+	}
+
+	admin, err := h.isAdmin(sm.scope(), sm.user)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return h.chat.PostMessage(sm.channel, "Only admins can change admins")
+	}
+	if userId == "" {
+		return h.chat.PostMessage(sm.channel, fmt.Sprintf("Who? Try %s admins %s @someone", botName, change))
+	}
+	name, err := h.chat.NameForUser(userId)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(change) {
+	case "add":
+		if err := h.db.putChannelAdmin(sm.scope(), userId); err != nil {
+			return err
+		}
+		return h.chat.PostMessage(sm.channel, fmt.Sprintf("%s is now an admin of this channel", name))
+	case "remove":
+		if err := h.db.deleteChannelAdmin(sm.scope(), userId); err != nil {
+			return err
+		}
+		return h.chat.PostMessage(sm.channel, fmt.Sprintf("%s is no longer an admin of this channel", name))
+	}
+	return h.chat.PostMessage(sm.channel, fmt.Sprintf("I don't understand %q. Try %s admins add|remove @someone", change, botName))
+}
+
+// handleAudit lists the channel's recent changes by admins
+func (h *HTTPHandler) handleAudit(sm ChatMessage, args commandArgs) error {
+	entries, err := h.db.getAudit(sm.scope(), auditShown)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return h.chat.PostMessage(sm.channel, "Admins haven't changed any results")
+	}
+	msg := "Recent changes by admins:"
+	for _, e := range entries {
+		line, err := h.auditMessage(e)
+		if err != nil {
+			return err
+		}
+		msg += fmt.Sprintf("\n%s %s", e.changedAt.Format("Jan 2 15:04"), strings.TrimPrefix(line, ":memo: "))
+	}
+	return h.chat.PostMessage(sm.channel, msg)
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"wordleturtle/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseScore(t *testing.T) {
+	tests := []struct {
+		game  Game
+		arg   string
+		score int
+		ok    bool
+	}{
+		{wordle{}, "3", 3, true},
+		{wordle{}, "3/6", 3, true},
+		{wordle{}, "X", 7, true},
+		{wordle{}, "x/6", 7, true},
+		{connections{}, "failed", connectionsFailed, true},
+		{mini{}, "1:23", 83, true},
+		{strands{}, "x", 0, false},
+		{wordle{}, "three", 0, false},
+		{wordle{}, "-1", 0, false},
+		{wordle{}, "12", 0, false},
+		{wordle{}, "0", 0, false},
+		{connections{}, "5", 0, false},
+		{mini{}, "0:00", 0, false},
+		{quordle{}, "20", 20, true},
+		{quordle{}, "x", quordleMissed + 3, true},
+		{quordle{}, "3", 0, false},
+		{quordle{}, "37", 0, false},
+	}
+	for _, tt := range tests {
+		score, err := parseScore(tt.game, tt.arg)
+		if !tt.ok {
+			assert.Error(t, err, tt.arg)
+			continue
+		}
+		require.NoError(t, err, tt.arg)
+		assert.Equal(t, tt.score, score, tt.arg)
+	}
+
+	num, err := parsePuzzle("#1,283")
+	require.NoError(t, err)
+	assert.Equal(t, 1283, num)
+	_, err = parsePuzzle("today")
+	assert.Error(t, err)
+}
+
+func Test_adminCommands(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	morning := DayForWordle(1283).Add(9 * time.Hour)
+	h := &HTTPHandler{config: &config.BotConfig{Admins: []string{"userid3"}}, db: db, chat: mockSlack, clock: newFakeClock(morning)}
+	command := func(user, text string) error {
+		fields := strings.Fields(text)
+		return h.handleCommand(ChatMessage{team: "testteam", channel: "testchannel", user: user}, fields[0], fields[1:])
+	}
+
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+	mockSlack.On("NameForUser", "userid3").Return("pat", nil)
+	mockSlack.On("IsAdmin", "testchannel", "userid1").Return(true, nil)
+	mockSlack.On("IsAdmin", "testchannel", "userid2").Return(false, nil)
+
+	posted := makeResult("userid1", "sean", 1283, 4)
+	posted.team, posted.channel, posted.ts = "testteam", "testchannel", "1700000000.000100"
+	require.NoError(t, db.putResult(posted))
+
+	mockSlack.On("PostMessage", "testchannel", "Only admins can use override").Return(nil).Once()
+	require.NoError(t, command("userid2", "override <@userid1> 1283 3"))
+
+	// Announced in the result's thread
+	mockSlack.On("PostThreadReply", "testchannel", posted.ts, ":memo: pat changed sean's Wordle #1283 from 4/6 to 3/6").Return(nil).Once()
+	require.NoError(t, command("userid3", "override <@userid1> #1,283 3/6"))
+	dailies, err := db.getDailyResults(testScope, "Wordle", 1283)
+	require.NoError(t, err)
+	require.Len(t, dailies, 1)
+	assert.Equal(t, 3, dailies[0].score)
+
+	mockSlack.On("PostMessage", "testchannel", "There's no Wordle #1280 result for <@userid2>. Try WordleTurtle backfill").Return(nil).Once()
+	require.NoError(t, command("userid3", "override <@userid2> 1280 3"))
+	mockSlack.On("PostMessage", "testchannel", "Wordle #1284 isn't out yet. Today's is Wordle #1283").Return(nil).Once()
+	require.NoError(t, command("userid3", "backfill <@userid2> 1284 4"))
+
+	// No result or summary to reply to
+	mockSlack.On("PostMessage", "testchannel", ":memo: pat added Wordle #1280 for lara (x/6)").Return(nil).Once()
+	require.NoError(t, command("userid3", "backfill <@userid2> 1280 X"))
+	dailies, err = db.getDailyResults(testScope, "Wordle", 1280)
+	require.NoError(t, err)
+	require.Len(t, dailies, 1)
+	assert.Equal(t, 7, dailies[0].score)
+	assert.Equal(t, "lara", dailies[0].displayName)
+	mockSlack.On("PostMessage", "testchannel", "<@userid2> already has x/6 for Wordle #1280. Try WordleTurtle override").Return(nil).Once()
+	require.NoError(t, command("userid3", "backfill <@userid2> 1280 5"))
+
+	mockSlack.On("PostThreadReply", "testchannel", posted.ts, ":memo: pat deleted sean's Wordle #1283 (3/6)").Return(nil).Once()
+	require.NoError(t, command("userid3", "delete <@userid1> 1283"))
+	dailies, err = db.getDailyResults(testScope, "Wordle", 1283)
+	require.NoError(t, err)
+	assert.Empty(t, dailies)
+
+	// Channel admins
+	mockSlack.On("PostMessage", "testchannel", "Only admins can change admins").Return(nil).Once()
+	require.NoError(t, command("userid2", "admins add <@userid2>"))
+	mockSlack.On("PostMessage", "testchannel", "lara is now an admin of this channel").Return(nil).Once()
+	require.NoError(t, command("userid1", "admins add <@userid2>"))
+	mockSlack.On("PostMessage", "testchannel", "Admins of this channel: lara").Return(nil).Once()
+	require.NoError(t, command("userid2", "admins"))
+
+	mockSlack.On("PostMessage", "testchannel", "Recent changes by admins:"+
+		"\nDec 23 09:00 pat deleted sean's Wordle #1283 (3/6)"+
+		"\nDec 23 09:00 pat added Wordle #1280 for lara (x/6)"+
+		"\nDec 23 09:00 pat changed sean's Wordle #1283 from 4/6 to 3/6").Return(nil).Once()
+	require.NoError(t, command("userid2", "audit"))
+
+	mockSlack.On("PostMessage", "testchannel", "lara is no longer an admin of this channel").Return(nil).Once()
+	require.NoError(t, command("userid2", "admins remove <@userid2>"))
+	mockSlack.On("PostMessage", "testchannel", "Only admins can use audit").Return(nil).Once()
+	require.NoError(t, command("userid2", "audit"))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_adminCommands_Rerates(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{config: &config.BotConfig{Admins: []string{"userid3"}}, db: db, chat: mockSlack, clock: newFakeClock(DayForWordle(1284).Add(9 * time.Hour))}
+	mockSlack.On("NameForUser", mock.Anything).Return("someone", nil)
+	mockSlack.On("PostMessage", "testchannel", mock.Anything).Return(nil)
+
+	// sean beat lara on both days
+	for _, num := range []int{1282, 1283} {
+		dailies := []Result{makeResult("userid1", "sean", num, 3), makeResult("userid2", "lara", num, 4)}
+		for _, res := range dailies {
+			res.team, res.channel = "testteam", "testchannel"
+			require.NoError(t, db.putResult(res))
+		}
+		_, err := updateRatings(db, testScope, wordle{}, num, dailies)
+		require.NoError(t, err)
+	}
+
+	// Until an admin fixes the first day, when lara won
+	require.NoError(t, h.handleCommand(ChatMessage{team: "testteam", channel: "testchannel", user: "userid3"}, "override", []string{"<@userid2>", "1282", "2"}))
+
+	ratings, err := db.getRatings(testScope, "Wordle")
+	require.NoError(t, err)
+	want := rateDay(wordle{}, nil, []Result{makeResult("userid1", "", 1282, 3), makeResult("userid2", "", 1282, 2)}, 1282)
+	want = rateDay(wordle{}, want, []Result{makeResult("userid1", "", 1283, 3), makeResult("userid2", "", 1283, 4)}, 1283)
+	require.Len(t, ratings, 2)
+	for i := range want {
+		assert.Equal(t, want[i].userId, ratings[i].userId)
+		assert.InDelta(t, want[i].rating, ratings[i].rating, 0.001)
+		assert.Equal(t, 1283, ratings[i].wordlenum)
+	}
+}
@@ -62,8 +62,7 @@ func getConsolation() string {
 	return getRandomString(consolations)
 }
 
-func getSpecialDayAffirmation(score int) string {
-	today := NowDefault()
+func getSpecialDayAffirmation(score int, today time.Time) string {
 	if today.Day() == 25 && today.Month() == time.December {
 		var messages []string
 		if score <= 4 {
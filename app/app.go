@@ -2,10 +2,11 @@ package app
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 	"wordleturtle/config"
 
@@ -14,8 +15,6 @@ import (
 	"github.com/slack-go/slack/slackevents"
 )
 
-var scheduled_wordles map[int]struct{} = make(map[int]struct{})
-
 type (
 	// Handler is an interface for the webserver that handles
 	// incoming requests from Slack events API
@@ -30,28 +29,36 @@ type (
 		Handler
 		config *config.BotConfig
 		db     DB
-		slack  SlackConnection
+		chat   ChatConnection
+		// clock is the real one when nil
+		clock Clock
+		// working stops us processing the event queue twice at once
+		working sync.Mutex
 	}
 )
 
-type SlackMessage struct {
-	channel string
-	user    string
-	text    string
-}
-
-func ConvertSlackMessage(me slackevents.MessageEvent) SlackMessage {
-	return SlackMessage{
-		channel: me.Channel,
-		user:    me.User,
-		text:    me.Text,
+func ConvertSlackMessage(team string, me slackevents.MessageEvent) ChatMessage {
+	return ChatMessage{
+		team:     team,
+		channel:  me.Channel,
+		user:     me.User,
+		text:     me.Text,
+		ts:       me.TimeStamp,
+		postedAt: slackTime(me.TimeStamp),
 	}
 }
 
 // NewHandler creates slack events api handler
-// It creates HTTPHandler for development environment
+// It creates SocketModeHandler or DiscordHandler when configured for them,
+// and HTTPHandler otherwise
 func NewHandler(c *config.BotConfig) Handler {
-	h := &HTTPHandler{}
+	var h Handler = &HTTPHandler{}
+	switch c.Transport {
+	case config.TransportSocketMode:
+		h = &SocketModeHandler{}
+	case config.TransportDiscord:
+		h = &DiscordHandler{}
+	}
 	h.Init(c)
 	return h
 
@@ -59,14 +66,36 @@ func NewHandler(c *config.BotConfig) Handler {
 
 // Init initializes handler
 func (h *HTTPHandler) Init(c *config.BotConfig) {
-	h.config = c
-	h.db, _ = NewSQLiteDB("./wordles")
-	h.slack = NewSlackAPIConnection(h.config.SlackBotToken)
+	h.setup(c, NewSlackAPIConnection(c.SlackBotToken, c.MembersCacheTTL))
 	http.HandleFunc("/", h.handle)
+	http.HandleFunc("/tick", h.handleTick)
+	http.HandleFunc("/work", h.handleWork)
+	http.HandleFunc("/slash", h.handleSlashCommand)
 }
 
-// handle handles incoming data from
-func (h *HTTPHandler) handle(w http.ResponseWriter, r *http.Request) {
+// setup connects to the database, and talks to people through chat
+func (h *HTTPHandler) setup(c *config.BotConfig, chat ChatConnection) {
+	h.config = c
+	db, err := NewDB(h.config)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	h.db = db
+	h.chat = chat
+	h.clock = realClock{}
+}
+
+// now is the current time according to the handler's clock
+func (h *HTTPHandler) now() time.Time {
+	if h.clock == nil {
+		return NowDefault()
+	}
+	return h.clock.Now()
+}
+
+// verifiedBody reads a request from Slack, checking its signature. If it
+// can't, it responds with an error and returns false.
+func (h *HTTPHandler) verifiedBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
 	body, err := io.ReadAll(r.Body)
 
 	log.Printf("Got request: %v", string(body))
@@ -75,22 +104,31 @@ func (h *HTTPHandler) handle(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Print(err)
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return nil, false
 	}
 	sv, err := slack.NewSecretsVerifier(r.Header, h.config.SigningSecret)
 	if err != nil {
 		log.Print(err)
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return nil, false
 	}
 	if _, err := sv.Write(body); err != nil {
 		log.Print(err)
 		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil, false
 	}
 	if err := sv.Ensure(); err != nil {
 		log.Print(err)
 		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+	return body, true
+}
+
+// handle handles incoming data from
+func (h *HTTPHandler) handle(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.verifiedBody(w, r)
+	if !ok {
 		return
 	}
 
@@ -112,22 +150,60 @@ func (h *HTTPHandler) handle(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(r.Challenge))
 	}
 	if eventsAPIEvent.Type == slackevents.CallbackEvent {
-		innerEvent := eventsAPIEvent.InnerEvent
-		switch ev := innerEvent.Data.(type) {
-		case *slackevents.MessageEvent:
-			log.Println(ev)
-			err := h.handleUserMessage(ConvertSlackMessage(*ev))
-			if err != nil {
-				log.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		// Queue the event and ack straight away. Slack retries anything that
+		// takes more than 3 seconds, and we'd rather not congratulate twice.
+		callback, ok := eventsAPIEvent.Data.(*slackevents.EventsAPICallbackEvent)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if retry := r.Header.Get("X-Slack-Retry-Num"); retry != "" {
+			log.Printf("Slack retry %s of %s: %s", retry, callback.EventID, r.Header.Get("X-Slack-Retry-Reason"))
+		}
+		queued, err := h.db.putEvent(callback.EventID, body, h.now())
+		if err != nil {
+			log.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if queued {
+			h.kickWorker()
+		}
+	}
+}
+
+// dispatchEvent does the work for an event that was queued by handle
+func (h *HTTPHandler) dispatchEvent(eventsAPIEvent slackevents.EventsAPIEvent) error {
+	innerEvent := eventsAPIEvent.InnerEvent
+	switch ev := innerEvent.Data.(type) {
+	case *slackevents.MessageEvent:
+		log.Println(ev)
+		switch ev.SubType {
+		case "message_changed":
+			return h.handleMessageChanged(eventsAPIEvent.TeamID, ev)
+		case "message_deleted":
+			return h.handleMessageDeleted(eventsAPIEvent.TeamID, ev)
+		default:
+			return h.handleUserMessage(ConvertSlackMessage(eventsAPIEvent.TeamID, *ev))
 		}
+	case *slackevents.MemberJoinedChannelEvent:
+		h.forgetMembers(ev.Channel)
+	case *slackevents.MemberLeftChannelEvent:
+		h.forgetMembers(ev.Channel)
+	}
+	return nil
+}
+
+// forgetMembers makes the next GetUsers for a channel look its members up
+// afresh, when the connection caches them
+func (h *HTTPHandler) forgetMembers(channel string) {
+	if cache, ok := h.chat.(memberCache); ok {
+		cache.forgetMembers(channel)
 	}
 }
 
-func (h *HTTPHandler) handleUserMessage(sm SlackMessage) error {
-	user, err := h.slack.NameForUser(sm.user)
+func (h *HTTPHandler) handleUserMessage(sm ChatMessage) error {
+	user, err := h.chat.NameForUser(sm.user)
 	if err != nil {
 		return err
 	}
@@ -139,128 +215,135 @@ func (h *HTTPHandler) handleUserMessage(sm SlackMessage) error {
 		return nil
 	}
 
-	// Commands - Message starts with @WordleTurtle
-	iscmd, cmd := isCommandMessage(sm.text)
+	// Commands - Message starts with WordleTurtle or @WordleTurtle
+	text, iscmd, err := h.commandText(sm.text)
+	if err != nil {
+		return err
+	}
 	if iscmd {
-		return h.handleCommand(sm, cmd)
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			return h.handleCommand(sm, "help", nil)
+		}
+		return h.handleCommand(sm, strings.ToLower(fields[0]), fields[1:])
 	}
 
 	// TODO - handle errors,
-	// Does the text contain a wordle style message?
-	res := extractWordleResult(sm.text)
+	// Does the text contain a result for one of our games?
+	res := extractMessageResult(sm, user)
 	if res != nil {
-		res.userId = sm.user
-		res.displayName = user
 		return h.handleWordle(sm, res)
 	}
 
 	return nil
 }
 
-func (h *HTTPHandler) handleCommand(sm SlackMessage, cmd string) error {
-	var err error
-	switch cmd {
-	case "help":
-		commands := `Supported commands are:
-help - display this help text
-leaderboard - display the weekly leaderboard`
-		err = h.slack.PostMessage(sm.channel, commands)
-	case "leaderboard":
-		wordlenum, err := h.db.getLargestWordle()
-		if err != nil {
-			return err
-		}
-		slackPost, err := getLeaderBoardPost(h.db, h.slack, wordlenum, sm.channel)
-		if err != nil {
-			return err
-		}
-		err = h.slack.PostMessage(sm.channel, slackPost)
-		if err != nil {
-			return err
-		}
+// extractMessageResult parses a result out of a message from user
+func extractMessageResult(sm ChatMessage, user string) *Result {
+	res := extractResult(sm.text)
+	if res == nil {
+		return nil
 	}
-	return err
+	res.team = sm.team
+	res.channel = sm.channel
+	res.userId = sm.user
+	res.displayName = user
+	res.ts = sm.ts
+	return res
 }
 
-func (h *HTTPHandler) handleWordle(sm SlackMessage, res *Result) error {
+func (h *HTTPHandler) handleWordle(sm ChatMessage, res *Result) error {
+	// Only count puzzles being played today
+	if ok, err := h.checkResult(sm, res); !ok {
+		return err
+	}
+	return h.recordResult(sm, res)
+}
 
+// recordResult saves a result that passed checkResult, updates the live
+// summary and replies to it. The reply comes last, as everything before it is
+// safe to repeat when the event is retried.
+func (h *HTTPHandler) recordResult(sm ChatMessage, res *Result) error {
 	// record it in the database
-	h.db.putResult(*res)
+	if err := h.db.putResult(*res); err != nil {
+		return err
+	}
 	// Look up the other results for the day
-	dailies, _ := h.db.getDailyResults(res.wordlenum)
-	log.Printf("we have %d results", len(dailies))
-
-	// Look up the number of users in the chat (minus wordleturtle)
-	// TODO - handle pagination
-	users, err := h.slack.GetUsers(sm.channel)
+	dailies, err := h.db.getDailyResults(res.scope(), res.game, res.wordlenum)
 	if err != nil {
 		return err
 	}
-	log.Printf("we have %d users", len(users))
-	missing := getMissingPlayers(h.slack, users, dailies)
+	log.Printf("we have %d results", len(dailies))
 
-	// If we haven't scheduled a deadline message, do so now
-	if _, ok := scheduled_wordles[res.wordlenum]; !ok {
-		// TODO - block old wordles from being posted and getting a deadline
-		scheduled_wordles[res.wordlenum] = struct{}{}
-		// Schedule 2 messages: a reminder before deadline and then final results
-		go h.postEndOfDay(*res, sm.channel)
+	// Schedule the reminders before the deadline and then the final results
+	if err := h.scheduleEndOfDay(*res); err != nil {
+		log.Println(err)
 	}
 
-	slackPost := getWordlePost(*res, dailies, users, missing)
-	err = h.slack.PostMessage(sm.channel, slackPost)
-	return err
+	if err := h.updateLiveSummary(res.scope(), res.game, res.wordlenum, dailies); err != nil {
+		return err
+	}
+	if reply := getWordleReply(*res, dailies, h.now()); reply != "" {
+		return h.chat.PostThreadReply(sm.channel, sm.ts, reply)
+	}
+	return nil
 }
 
-func (h *HTTPHandler) postEndOfDay(exemplar Result, channel string) {
-	log.Printf("Scheduling deadline for wordle %d", exemplar.wordlenum)
-	// deadline 5PM PT
-	base := DayForWordle(exemplar.wordlenum)
-	now := NowDefault()
-
-	if now.Sub(base).Hours() > 24 {
-		log.Printf("Not scheduling old wordle: %v", exemplar)
-		return
+// refreshLiveSummary brings a puzzle's summary up to date after results
+// change, if it has one
+func (h *HTTPHandler) refreshLiveSummary(sc scope, game string, wordlenum int) error {
+	ts, err := h.db.getSummaryTs(sc, game, wordlenum)
+	if err != nil || ts == "" {
+		return err
 	}
-	deadline := time.Date(base.Year(), base.Month(), base.Day(), 17, 0, 0, 0, base.Location())
-	if base.After(deadline) {
-		deadline = deadline.AddDate(0, 0, 1)
+	dailies, err := h.db.getDailyResults(sc, game, wordlenum)
+	if err != nil {
+		return err
 	}
-	predeadline := deadline.Add(-1 * time.Hour)
-
-	log.Printf("Sleeping until predeadline for wordle %d: %s", exemplar.wordlenum, predeadline.String())
-
-	time.Sleep(time.Until(predeadline))
-	h.slack.PostMessage(channel, fmt.Sprintf(":hourglass: 1 hour to deadline for Wordle #%d! :hourglass:", exemplar.wordlenum))
-	time.Sleep(time.Until(deadline))
-
-	dailies, _ := h.db.getDailyResults(exemplar.wordlenum)
-	leaders := getLeaders(dailies)
-	summaryMsg := makeSummaryPositionMessage(dailies)
-
-	users, _ := h.slack.GetUsers(channel)
-	missing := getMissingPlayers(h.slack, users, dailies)
-
-	msg := fmt.Sprintf(":confetti_ball: Congratulations to %s! :confetti_ball:\nFinal %s", leaderString(leaders), summaryMsg)
+	return h.updateLiveSummary(sc, game, wordlenum, dailies)
+}
 
-	if len(missing) > 0 {
-		msg += fmt.Sprintf("\n:turkey: %s forgot to show up!", namesString(missing))
+// updateLiveSummary edits the puzzle's summary message to show dailies,
+// posting it if there isn't one yet
+func (h *HTTPHandler) updateLiveSummary(sc scope, game string, wordlenum int, dailies []Result) error {
+	// Look up the number of users in the chat (minus wordleturtle)
+	users, err := h.chat.GetUsers(sc.channel)
+	if err != nil {
+		return err
 	}
-	h.slack.PostMessage(channel, msg)
+	log.Printf("we have %d users", len(users))
+	players, err := h.db.getParticipation(sc)
+	if err != nil {
+		return err
+	}
+	users = expectedPlayers(users, players, gameFor(Result{game: game}).DayForPuzzle(wordlenum))
+	missing := getMissingPlayers(h.chat, users, dailies)
+	slackPost := getWordlePost(dailies, missing)
 
-	// If Saturday, post the weekly leaderboard
-	if base.Weekday() == 6 {
-		leaderboard, err := getLeaderBoardPost(h.db, h.slack, exemplar.wordlenum, channel)
+	ts, err := h.db.getSummaryTs(sc, game, wordlenum)
+	if err != nil {
+		return err
+	}
+	if ts != "" {
+		err := h.chat.UpdateRichMessage(sc.channel, ts, slackPost)
 		if err == nil {
-			slackPost := "Weekly Leaderboard\n" + leaderboard
-			h.slack.PostMessage(channel, slackPost)
+			return nil
 		}
+		// Most likely someone deleted it, so start a new one
+		log.Printf("Failed to update summary %s: %v", ts, err)
+	}
+
+	ts, err = h.chat.PostRichMessage(sc.channel, slackPost)
+	if err != nil {
+		return err
 	}
+	return h.db.putSummaryTs(sc, game, wordlenum, ts)
 }
 
 // Start starts the server
 func (h *HTTPHandler) Start() error {
 	if h.config.Env == config.EnvDevelopment {
+		go h.runTicker(h.config.TickInterval)
 		return http.ListenAndServe(h.config.BindAddr, nil)
 	}
 	algnhsa.ListenAndServe(http.DefaultServeMux, nil)
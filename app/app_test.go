@@ -1,8 +1,12 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -26,11 +30,36 @@ func (m *MockSlack) PostMessage(channel, msg string) error {
 	return args.Error(0)
 }
 
+func (m *MockSlack) PostRichMessage(channel string, msg RichMessage) (string, error) {
+	args := m.Called(channel, msg)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSlack) UpdateRichMessage(channel, ts string, msg RichMessage) error {
+	args := m.Called(channel, ts, msg)
+	return args.Error(0)
+}
+
+func (m *MockSlack) PostThreadReply(channel, threadTs, msg string) error {
+	args := m.Called(channel, threadTs, msg)
+	return args.Error(0)
+}
+
 func (m *MockSlack) GetUsers(channel string) ([]string, error) {
 	args := m.Called(channel)
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockSlack) BotUserID() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSlack) IsAdmin(channel, userId string) (bool, error) {
+	args := m.Called(channel, userId)
+	return args.Bool(0), args.Error(1)
+}
+
 type MockDB struct {
 	mock.Mock
 }
@@ -40,22 +69,210 @@ func (m *MockDB) putResult(result Result) error {
 	return args.Error(0)
 }
 
-func (m *MockDB) getDailyResults(wordlenum int) ([]Result, error) {
-	args := m.Called(wordlenum)
+func (m *MockDB) getDailyResults(sc scope, game string, wordlenum int) ([]Result, error) {
+	args := m.Called(sc, game, wordlenum)
 	return args.Get(0).([]Result), args.Error(1)
 }
 
-func (m *MockDB) getLargestWordle() (int, error) {
-	args := m.Called()
+func (m *MockDB) getLargestWordle(sc scope, game string) (int, error) {
+	args := m.Called(sc, game)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDB) getPlayerResults(sc scope, game string, userId string) ([]Result, error) {
+	args := m.Called(sc, game, userId)
+	return args.Get(0).([]Result), args.Error(1)
+}
+
+func (m *MockDB) getChannelResults(sc scope, game string) ([]Result, error) {
+	args := m.Called(sc, game)
+	return args.Get(0).([]Result), args.Error(1)
+}
+
+func (m *MockDB) getResultsInRange(sc scope, game string, from, to int) ([]Result, error) {
+	args := m.Called(sc, game, from, to)
+	return args.Get(0).([]Result), args.Error(1)
+}
+
+func (m *MockDB) getRatings(sc scope, game string) ([]Rating, error) {
+	args := m.Called(sc, game)
+	return args.Get(0).([]Rating), args.Error(1)
+}
+
+func (m *MockDB) putRating(sc scope, game string, rating Rating) error {
+	args := m.Called(sc, game, rating)
+	return args.Error(0)
+}
+
+func (m *MockDB) getRatingsBefore(sc scope, game string, wordlenum int) ([]Rating, error) {
+	args := m.Called(sc, game, wordlenum)
+	return args.Get(0).([]Rating), args.Error(1)
+}
+
+func (m *MockDB) getRatedPuzzles(sc scope, game string, wordlenum int) ([]int, error) {
+	args := m.Called(sc, game, wordlenum)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockDB) getLastSeededPuzzle(sc scope, game string) (int, error) {
+	args := m.Called(sc, game)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockDB) deleteRatingsFrom(sc scope, game string, wordlenum int) error {
+	args := m.Called(sc, game, wordlenum)
+	return args.Error(0)
+}
+
+func (m *MockDB) getSummaryTs(sc scope, game string, wordlenum int) (string, error) {
+	args := m.Called(sc, game, wordlenum)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockDB) putSummaryTs(sc scope, game string, wordlenum int, ts string) error {
+	args := m.Called(sc, game, wordlenum, ts)
+	return args.Error(0)
+}
+
+func (m *MockDB) getResultByTs(sc scope, ts string) (*Result, error) {
+	args := m.Called(sc, ts)
+	return args.Get(0).(*Result), args.Error(1)
+}
+
+func (m *MockDB) deleteResult(result Result) error {
+	args := m.Called(result)
+	return args.Error(0)
+}
+
+func (m *MockDB) putEvent(id string, body []byte, receivedAt time.Time) (bool, error) {
+	args := m.Called(id, body, receivedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDB) getQueuedEvents(now time.Time) ([]QueuedEvent, error) {
+	args := m.Called(now)
+	return args.Get(0).([]QueuedEvent), args.Error(1)
+}
+
+func (m *MockDB) claimEvent(id string, now time.Time) (bool, error) {
+	args := m.Called(id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDB) finishEvent(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDB) releaseEvent(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDB) deleteEventsBefore(t time.Time) error {
+	args := m.Called(t)
+	return args.Error(0)
+}
+
+func (m *MockDB) putJob(job Job) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockDB) getDueJobs(now time.Time) ([]Job, error) {
+	args := m.Called(now)
+	return args.Get(0).([]Job), args.Error(1)
+}
+
+func (m *MockDB) claimJob(id int64, now time.Time) (bool, error) {
+	args := m.Called(id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDB) finishJob(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDB) releaseJob(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDB) getPendingJobs(sc scope) ([]Job, error) {
+	args := m.Called(sc)
+	return args.Get(0).([]Job), args.Error(1)
+}
+
+func (m *MockDB) unscheduleJob(id int64) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDB) getChannelSettings(sc scope) (ChannelSettings, error) {
+	args := m.Called(sc)
+	return args.Get(0).(ChannelSettings), args.Error(1)
+}
+
+func (m *MockDB) putChannelSettings(sc scope, settings ChannelSettings) error {
+	args := m.Called(sc, settings)
+	return args.Error(0)
+}
+
+func (m *MockDB) putRejection(rejection Rejection) error {
+	args := m.Called(rejection)
+	return args.Error(0)
+}
+
+func (m *MockDB) getRejections(sc scope, limit int) ([]Rejection, error) {
+	args := m.Called(sc, limit)
+	return args.Get(0).([]Rejection), args.Error(1)
+}
+
+func (m *MockDB) getChannelAdmins(sc scope) ([]string, error) {
+	args := m.Called(sc)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDB) putChannelAdmin(sc scope, userId string) error {
+	args := m.Called(sc, userId)
+	return args.Error(0)
+}
+
+func (m *MockDB) deleteChannelAdmin(sc scope, userId string) error {
+	args := m.Called(sc, userId)
+	return args.Error(0)
+}
+
+func (m *MockDB) putAudit(entry AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockDB) getAudit(sc scope, limit int) ([]AuditEntry, error) {
+	args := m.Called(sc, limit)
+	return args.Get(0).([]AuditEntry), args.Error(1)
+}
+
+func (m *MockDB) getParticipation(sc scope) (map[string]Participation, error) {
+	args := m.Called(sc)
+	return args.Get(0).(map[string]Participation), args.Error(1)
+}
+
+func (m *MockDB) putParticipation(sc scope, userId string, p Participation) error {
+	args := m.Called(sc, userId, p)
+	return args.Error(0)
+}
+
 // =======
 // Helpers
 // =======
 
+var testScope = scope{team: "testteam", channel: "testchannel"}
+
 func makeResult(id, name string, wordlenum, score int) Result {
 	return Result{
+		game:        "Wordle",
 		wordlenum:   wordlenum,
 		userId:      id,
 		displayName: name,
@@ -75,40 +292,180 @@ func Test_handlesWordle(t *testing.T) {
 	h := &HTTPHandler{
 		config: nil,
 		db:     mockDb,
-		slack:  mockSlack,
+		chat:   mockSlack,
 	}
 
-	sm := SlackMessage{
+	today := WordleForDay(NowDefault())
+	sm := ChatMessage{
+		team:    "testteam",
 		channel: "testchannel",
-		text:    "Wordle 917 3/6*",
+		text:    fmt.Sprintf("Wordle %d 3/6*", today),
 		user:    "userid1",
+		ts:      "1700000000.000100",
 	}
 
 	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1", "userid2"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
 	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
 	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
 
-	resultMatcher := mock.MatchedBy(func(msg string) bool {
-		matches, err := regexp.Match(`^(?s).*\n3/6: sean.*`, []byte(msg))
+	// The affirmation goes in a thread on the result
+	mockSlack.On("PostThreadReply", "testchannel", "1700000000.000100", mock.Anything).Return(nil)
+	resultMatcher := mock.MatchedBy(func(msg RichMessage) bool {
+		matches, err := regexp.Match(`^(?s)Current Results.*\n3/6: sean.*`, []byte(msg.text))
 		return matches && err == nil
 	})
-	mockSlack.On("PostMessage", "testchannel", resultMatcher).Return(nil)
+	mockSlack.On("PostRichMessage", "testchannel", resultMatcher).Return("1700000000.000200", nil)
+	mockDb.On("getSummaryTs", testScope, "Wordle", today).Return("", nil)
+	mockDb.On("putSummaryTs", testScope, "Wordle", today, "1700000000.000200").Return(nil)
 
 	expectedResult := Result{
-		wordlenum:   917,
+		team:        "testteam",
+		channel:     "testchannel",
+		game:        "Wordle",
+		wordlenum:   today,
 		userId:      "userid1",
 		displayName: "sean",
 		score:       3,
 		hardmode:    1,
+		ts:          "1700000000.000100",
 	}
 	mockDb.On("putResult", expectedResult).Return(nil)
-	mockDb.On("getDailyResults", 917).Return([]Result{expectedResult}, nil)
+	mockDb.On("getDailyResults", testScope, "Wordle", today).Return([]Result{expectedResult}, nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockDb.On("putJob", mock.Anything).Return(nil)
 
 	assert.Nil(t, h.handleUserMessage(sm))
 
 	// Verify the deadline has been scheduled
-	_, ok := scheduled_wordles[917]
-	assert.True(t, ok)
+	mockDb.AssertCalled(t, "putJob", mock.MatchedBy(func(job Job) bool {
+		return job.kind == jobDeadline && job.wordlenum == today && job.scope() == testScope
+	}))
+	mockSlack.AssertExpectations(t)
+	mockDb.AssertCalled(t, "putSummaryTs", testScope, "Wordle", today, "1700000000.000200")
+}
+
+func Test_handlesWordle_Rejected(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	now := DayForWordle(1283).Add(9 * time.Hour)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack, clock: newFakeClock(now)}
+
+	sm := ChatMessage{team: "testteam", channel: "testchannel", text: "Wordle 9,999 1/6", user: "userid1", ts: "1700000000.000100"}
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	reason := "Wordle #9999 isn't out yet here, so I can't count it. Today's is Wordle #1283"
+	mockDb.On("putRejection", mock.MatchedBy(func(r Rejection) bool {
+		return r.result.wordlenum == 9999 && r.result.userId == "userid1" && r.reason == reason && r.rejectedAt.Equal(now)
+	})).Return(nil)
+	mockSlack.On("PostThreadReply", "testchannel", "1700000000.000100", reason).Return(nil)
+
+	assert.Nil(t, h.handleUserMessage(sm))
+	mockDb.AssertExpectations(t)
+	mockSlack.AssertExpectations(t)
+	mockDb.AssertNotCalled(t, "putResult", mock.Anything)
+}
+
+func Test_handlesCommand_Rejections(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	rejected := makeResult("userid1", "sean", 9999, 1)
+	rejectedAt := time.Date(2024, 12, 23, 9, 0, 0, 0, DefaultLocation())
+	mockDb.On("getRejections", testScope, rejectionsShown).Return([]Rejection{{result: rejected, reason: "Wordle #9999 isn't out yet here", rejectedAt: rejectedAt}}, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+	mockSlack.On("IsAdmin", "testchannel", "userid1").Return(true, nil)
+	mockSlack.On("IsAdmin", "testchannel", "userid2").Return(false, nil)
+	mockDb.On("getChannelAdmins", testScope).Return([]string{}, nil)
+	mockSlack.On("PostMessage", "testchannel", "Recently rejected results:\nDec 23 09:00 sean: Wordle #9999 1/6 - Wordle #9999 isn't out yet here").Return(nil).Once()
+	mockSlack.On("PostMessage", "testchannel", "Only admins can use rejections").Return(nil).Once()
+
+	assert.Nil(t, h.handleUserMessage(ChatMessage{team: "testteam", channel: "testchannel", user: "userid1", text: "WordleTurtle rejections"}))
+	assert.Nil(t, h.handleUserMessage(ChatMessage{team: "testteam", channel: "testchannel", user: "userid2", text: "WordleTurtle rejections"}))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_handlesWordle_UpdatesSummary(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+
+	h := &HTTPHandler{
+		config: nil,
+		db:     mockDb,
+		chat:   mockSlack,
+	}
+
+	today := WordleForDay(NowDefault())
+	sm := ChatMessage{
+		team:    "testteam",
+		channel: "testchannel",
+		text:    fmt.Sprintf("Wordle %d 4/6", today),
+		user:    "userid2",
+		ts:      "1700000000.000300",
+	}
+
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1", "userid2"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+	mockSlack.On("PostThreadReply", "testchannel", "1700000000.000300", mock.Anything).Return(nil)
+
+	dailies := []Result{
+		makeResult("userid1", "sean", today, 3),
+		makeResult("userid2", "lara", today, 4),
+	}
+	mockDb.On("putResult", mock.Anything).Return(nil)
+	mockDb.On("getDailyResults", testScope, "Wordle", today).Return(dailies, nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("putJob", mock.Anything).Return(nil)
+	mockDb.On("getSummaryTs", testScope, "Wordle", today).Return("1700000000.000200", nil)
+
+	// Everyone has played, so the summary becomes the final one
+	finalMatcher := mock.MatchedBy(func(msg RichMessage) bool {
+		return strings.HasPrefix(msg.text, ":confetti_ball: Congratulations to sean!")
+	})
+	mockSlack.On("UpdateRichMessage", "testchannel", "1700000000.000200", finalMatcher).Return(nil)
+
+	assert.Nil(t, h.handleUserMessage(sm))
+	mockSlack.AssertExpectations(t)
+	mockSlack.AssertNotCalled(t, "PostRichMessage", mock.Anything, mock.Anything)
+}
+
+func Test_handlesWordle_SummaryFails(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	today := WordleForDay(NowDefault())
+	sm := ChatMessage{team: "testteam", channel: "testchannel", text: fmt.Sprintf("Wordle %d 3/6", today), user: "userid1", ts: "1700000000.000100"}
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockDb.On("putResult", mock.Anything).Return(nil)
+	mockDb.On("getDailyResults", testScope, "Wordle", today).Return([]Result{makeResult("userid1", "sean", today, 3)}, nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockDb.On("putJob", mock.Anything).Return(nil)
+	mockDb.On("getSummaryTs", testScope, "Wordle", today).Return("", nil)
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1"}, nil)
+
+	// Until the summary is posted the event is retried, so there's no reply
+	// yet to post again
+	mockSlack.On("PostRichMessage", "testchannel", mock.Anything).Return("", errors.New("slack is down")).Once()
+	assert.Error(t, h.handleUserMessage(sm))
+	mockSlack.AssertNotCalled(t, "PostThreadReply", mock.Anything, mock.Anything, mock.Anything)
+
+	mockSlack.On("PostRichMessage", "testchannel", mock.Anything).Return("1700000000.000200", nil).Once()
+	mockDb.On("putSummaryTs", testScope, "Wordle", today, "1700000000.000200").Return(nil)
+	mockSlack.On("PostThreadReply", "testchannel", "1700000000.000100", mock.Anything).Return(nil).Once()
+	assert.NoError(t, h.handleUserMessage(sm))
+	mockSlack.AssertExpectations(t)
+
+	// A result that can't be saved fails the event, to be retried
+	mockDb.On("putResult", mock.Anything).Unset()
+	mockDb.On("putResult", mock.Anything).Return(errors.New("db is down"))
+	assert.Error(t, h.handleUserMessage(sm))
 }
 
 func Test_handlesCommand(t *testing.T) {
@@ -118,10 +475,11 @@ func Test_handlesCommand(t *testing.T) {
 	h := &HTTPHandler{
 		config: nil,
 		db:     mockDb,
-		slack:  mockSlack,
+		chat:   mockSlack,
 	}
 
-	sm := SlackMessage{
+	sm := ChatMessage{
+		team:    "testteam",
 		channel: "testchannel",
 		text:    "WordleTurtle help",
 		user:    "userid1",
@@ -144,27 +502,30 @@ func Test_handlesCommand_Leaderboard(t *testing.T) {
 	h := &HTTPHandler{
 		config: nil,
 		db:     mockDb,
-		slack:  mockSlack,
+		chat:   mockSlack,
 	}
 
-	sm := SlackMessage{
+	sm := ChatMessage{
+		team:    "testteam",
 		channel: "testchannel",
 		text:    "WordleTurtle leaderboard",
 		user:    "userid1",
 	}
 
 	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1", "userid2", "userid3"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
 	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
 	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
 	mockSlack.On("NameForUser", "userid3").Return("grandma", nil)
 
-	resultMatcher := mock.MatchedBy(func(msg string) bool {
-		matches, err := regexp.Match(`(?s).*Player.*sean.*25.*grandma.*22.*lara.*20`, []byte(msg))
+	resultMatcher := mock.MatchedBy(func(msg RichMessage) bool {
+		matches, err := regexp.Match(`(?s)^Weekly Wordle Leaderboard\n.*Player.*sean.*25.*grandma.*22.*lara.*20`, []byte(msg.text))
 		return matches && err == nil
 	})
-	mockSlack.On("PostMessage", "testchannel", resultMatcher).Return(nil)
+	mockSlack.On("PostRichMessage", "testchannel", resultMatcher).Return("", nil)
 
-	mockDb.On("getLargestWordle").Return(917, nil)
+	mockDb.On("getLargestWordle", testScope, "Wordle").Return(917, nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
 
 	results := [][]Result{
 		{
@@ -199,13 +560,169 @@ func Test_handlesCommand_Leaderboard(t *testing.T) {
 		{},
 	}
 
-	mockDb.On("getDailyResults", 917).Return(results[0], nil)
-	mockDb.On("getDailyResults", 916).Return(results[1], nil)
-	mockDb.On("getDailyResults", 915).Return(results[2], nil)
-	mockDb.On("getDailyResults", 914).Return(results[3], nil)
-	mockDb.On("getDailyResults", 913).Return(results[4], nil)
-	mockDb.On("getDailyResults", 912).Return(results[5], nil)
-	mockDb.On("getDailyResults", 911).Return(results[6], nil)
+	// 917 is a Saturday, so the week started on Sunday with 911
+	week := []Result{}
+	for _, dailies := range results {
+		week = append(week, dailies...)
+	}
+	mockDb.On("getResultsInRange", testScope, "Wordle", 911, 917).Return(week, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+
+	assert.Nil(t, h.handleUserMessage(sm))
+}
+
+func Test_handlesCommand_Streak(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+
+	h := &HTTPHandler{
+		config: nil,
+		db:     mockDb,
+		chat:   mockSlack,
+	}
+
+	sm := ChatMessage{
+		team:    "testteam",
+		channel: "testchannel",
+		text:    "WordleTurtle streak <@userid2>",
+		user:    "userid1",
+	}
+
+	today := WordleForDay(NowDefault())
+	history := []Result{
+		makeResult("userid2", "lara", today-4, 4),
+		makeResult("userid2", "lara", today-2, 7),
+		makeResult("userid2", "lara", today-1, 3),
+	}
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("getPlayerResults", testScope, "Wordle", "userid2").Return(history, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+
+	resultMatcher := mock.MatchedBy(func(msg string) bool {
+		matches, err := regexp.Match(`(?s)lara's Wordle streaks.*Played: 2 days in a row \(best 2\).*Without an X: 1 day in a row \(best 1\)`, []byte(msg))
+		return matches && err == nil
+	})
+	mockSlack.On("PostMessage", "testchannel", resultMatcher).Return(nil)
+
+	assert.Nil(t, h.handleUserMessage(sm))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_handlesCommand_Stats(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+
+	h := &HTTPHandler{
+		config: nil,
+		db:     mockDb,
+		chat:   mockSlack,
+	}
+
+	sm := ChatMessage{
+		team:    "testteam",
+		channel: "testchannel",
+		text:    "WordleTurtle stats",
+		user:    "userid1",
+	}
+
+	mine := []Result{
+		makeResult("userid1", "sean", 100, 3),
+		makeResult("userid1", "sean", 101, 4),
+		makeResult("userid1", "sean", 102, 7),
+	}
+	everyone := append([]Result{
+		makeResult("userid2", "lara", 100, 2),
+		makeResult("userid2", "lara", 101, 3),
+	}, mine...)
+	mockDb.On("getPlayerResults", testScope, "Wordle", "userid1").Return(mine, nil)
+	mockDb.On("getChannelResults", testScope, "Wordle").Return(everyone, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+
+	resultMatcher := mock.MatchedBy(func(msg string) bool {
+		matches, err := regexp.Match(`(?s)sean's Wordle stats.*Played: 3.*Win %: 67.*Rank: 2 of 2`, []byte(msg))
+		return matches && err == nil
+	})
+	mockSlack.On("PostMessage", "testchannel", resultMatcher).Return(nil)
+
+	assert.Nil(t, h.handleUserMessage(sm))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_handlesCommand_LeaderboardPeriod(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+
+	h := &HTTPHandler{
+		config: nil,
+		db:     mockDb,
+		chat:   mockSlack,
+	}
+
+	sm := ChatMessage{
+		team:    "testteam",
+		channel: "testchannel",
+		text:    "WordleTurtle leaderboard month",
+		user:    "userid1",
+	}
+
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockDb.On("getLargestWordle", testScope, "Wordle").Return(1283, nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	// December 1st 2024 to the 23rd
+	mockDb.On("getResultsInRange", testScope, "Wordle", 1261, 1283).Return([]Result{makeResult("userid1", "sean", 1283, 3)}, nil)
+
+	resultMatcher := mock.MatchedBy(func(msg RichMessage) bool {
+		matches, err := regexp.Match(`(?s)^December 2024 Wordle Leaderboard\n.*sean.*5`, []byte(msg.text))
+		return matches && err == nil
+	})
+	mockSlack.On("PostRichMessage", "testchannel", resultMatcher).Return("", nil)
+
+	assert.Nil(t, h.handleUserMessage(sm))
+	mockSlack.AssertExpectations(t)
+
+	// Unknown periods get a hint
+	sm.text = "WordleTurtle leaderboard fortnight"
+	hintMatcher := mock.MatchedBy(func(msg string) bool {
+		return strings.HasPrefix(msg, `I don't know the game or period "fortnight"`)
+	})
+	mockSlack.On("PostMessage", "testchannel", hintMatcher).Return(nil)
+	assert.Nil(t, h.handleUserMessage(sm))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_handlesCommand_Ratings(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+
+	h := &HTTPHandler{
+		config: nil,
+		db:     mockDb,
+		chat:   mockSlack,
+	}
+
+	sm := ChatMessage{
+		team:    "testteam",
+		channel: "testchannel",
+		text:    "WordleTurtle ratings",
+		user:    "userid1",
+	}
+
+	mockDb.On("getRatings", testScope, "Wordle").Return([]Rating{
+		{userId: "userid1", rating: 1480, rd: 60, volatility: 0.06},
+		{userId: "userid2", rating: 1620.4, rd: 45, volatility: 0.06},
+	}, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+
+	resultMatcher := mock.MatchedBy(func(msg string) bool {
+		matches, err := regexp.Match(`(?s)^Wordle Ratings\n.*lara.*1620.*90.*sean.*1480.*120`, []byte(msg))
+		return matches && err == nil
+	})
+	mockSlack.On("PostMessage", "testchannel", resultMatcher).Return(nil)
 
 	assert.Nil(t, h.handleUserMessage(sm))
+	mockSlack.AssertExpectations(t)
 }
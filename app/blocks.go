@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// Slack refuses messages with more blocks than this, and sections with more
+// fields than sectionMaxFields
+const (
+	messageMaxBlocks = 50
+	sectionMaxFields = 10
+)
+
+// RichMessage is a Block Kit message. text is the plain version, shown in
+// notifications and by clients that can't render blocks.
+type RichMessage struct {
+	text   string
+	blocks []slack.Block
+}
+
+func markdownText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+}
+
+func markdownSection(text string) *slack.SectionBlock {
+	return slack.NewSectionBlock(markdownText(text), nil, nil)
+}
+
+func headerBlock(text string) *slack.HeaderBlock {
+	return slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, text, true, false))
+}
+
+func contextBlock(text string) *slack.ContextBlock {
+	return slack.NewContextBlock("", markdownText(text))
+}
+
+// makeSummaryPositionBlocks is makeSummaryPositionMessage as a title and a
+// field for each score
+func makeSummaryPositionBlocks(prefix string, results []Result) []slack.Block {
+	if len(results) == 0 {
+		return []slack.Block{markdownSection("No plays yet.")}
+	}
+	game := gameFor(results[0])
+
+	fields := []*slack.TextBlockObject{}
+	for _, group := range groupByScore(results) {
+		fields = append(fields, markdownText(fmt.Sprintf("*%s*\n%s", game.ScoreLabel(group.score), strings.Join(group.names, ", "))))
+	}
+
+	blocks := []slack.Block{markdownSection(fmt.Sprintf("*%s Results for %s*", prefix, game.PuzzleTitle(results[0].wordlenum)))}
+	for i := 0; i < len(fields); i += sectionMaxFields {
+		blocks = append(blocks, slack.NewSectionBlock(nil, fields[i:min(i+sectionMaxFields, len(fields))], nil))
+	}
+	return blocks
+}
+
+// finalSummaryPost congratulates the leaders and shows the final results,
+// followed by any notes (turkeys, milestones, ...)
+func finalSummaryPost(dailies []Result, notes []string) RichMessage {
+	leaders := getLeaders(dailies)
+	summaryMsg := makeSummaryPositionMessage(dailies)
+	summaryBlocks := makeSummaryPositionBlocks("Final", dailies)
+
+	var msg RichMessage
+	if len(leaders) == 0 {
+		msg.text = fmt.Sprintf("Final %s", summaryMsg)
+		msg.blocks = summaryBlocks
+	} else {
+		congrats := fmt.Sprintf(":confetti_ball: Congratulations to %s! :confetti_ball:", leaderString(leaders))
+		msg.text = fmt.Sprintf("%s\nFinal %s", congrats, summaryMsg)
+		msg.blocks = append([]slack.Block{markdownSection(congrats)}, summaryBlocks...)
+	}
+
+	for _, note := range notes {
+		msg.text += "\n" + note
+		msg.blocks = append(msg.blocks, contextBlock(note))
+	}
+	return msg
+}
+
+// leaderboardRow is one player's line in a leaderboard. counts has one entry
+// per bucket, then the turkeys.
+type leaderboardRow struct {
+	player string
+	score  int
+	counts []int
+}
+
+// leaderboardBlocks shows a section per player instead of a table, since
+// tables wrap badly on phones
+func leaderboardBlocks(heading string, buckets []string, standings []leaderboardRow, turkeys string) []slack.Block {
+	blocks := []slack.Block{headerBlock(heading)}
+
+	// Leave room for the header, the turkeys and the overflow note
+	shown := min(len(standings), messageMaxBlocks-3)
+	for i, row := range standings[:shown] {
+		counts := []string{}
+		for j, count := range row.counts {
+			if count == 0 {
+				continue
+			}
+			label := ":turkey:"
+			if j < len(buckets) {
+				label = buckets[j]
+			}
+			counts = append(counts, fmt.Sprintf("%s: %d", label, count))
+		}
+		blocks = append(blocks, markdownSection(fmt.Sprintf("*%d. %s* %s\n%s", i+1, row.player, pluralize(row.score, "point"), strings.Join(counts, " · "))))
+	}
+	if shown < len(standings) {
+		blocks = append(blocks, contextBlock(fmt.Sprintf("…and %d more", len(standings)-shown)))
+	}
+
+	if turkeys != "" {
+		blocks = append(blocks, contextBlock(turkeys))
+	}
+	return blocks
+}
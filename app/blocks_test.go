@@ -0,0 +1,62 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_makeSummaryPositionBlocks(t *testing.T) {
+	inputs := []Result{
+		makeResult("userid1", "sean", 917, 5),
+		makeResult("userid2", "lara", 917, 3),
+		makeResult("userid3", "grandma", 917, 3),
+	}
+
+	blocks := makeSummaryPositionBlocks("Current", inputs)
+	require.Len(t, blocks, 2)
+	assert.Equal(t, "*Current Results for Wordle #917*", blocks[0].(*slack.SectionBlock).Text.Text)
+	fields := blocks[1].(*slack.SectionBlock).Fields
+	require.Len(t, fields, 2)
+	assert.Equal(t, "*3/6*\nlara, grandma", fields[0].Text)
+	assert.Equal(t, "*5/6*\nsean", fields[1].Text)
+}
+
+func Test_finalSummaryPost(t *testing.T) {
+	inputs := []Result{makeResult("userid1", "sean", 917, 3)}
+
+	msg := finalSummaryPost(inputs, []string{":turkey: lara forgot to show up!"})
+	assert.Equal(t, ":confetti_ball: Congratulations to sean! :confetti_ball:\nFinal Results for Wordle #917:\n3/6: sean\n\n:turkey: lara forgot to show up!", msg.text)
+	require.Len(t, msg.blocks, 4)
+	assert.IsType(t, &slack.ContextBlock{}, msg.blocks[3])
+
+	// Slack has to be able to read what we send
+	_, err := json.Marshal(slack.Blocks{BlockSet: msg.blocks})
+	assert.NoError(t, err)
+}
+
+func Test_leaderboardBlocks(t *testing.T) {
+	standings := []leaderboardRow{
+		{player: "sean", score: 25, counts: []int{1, 1, 1, 0, 0, 1, 1, 1}},
+		{player: "lara", score: 1, counts: []int{0, 0, 0, 0, 0, 0, 1, 6}},
+	}
+
+	blocks := leaderboardBlocks("Weekly Wordle Leaderboard", wordle{}.Buckets(), standings, ":turkey: grandma forgot to show up!")
+	require.Len(t, blocks, 4)
+	assert.Equal(t, "Weekly Wordle Leaderboard", blocks[0].(*slack.HeaderBlock).Text.Text)
+	assert.Equal(t, "*1. sean* 25 points\n1s: 1 · 2s: 1 · 3s: 1 · 6s: 1 · Xs: 1 · :turkey:: 1", blocks[1].(*slack.SectionBlock).Text.Text)
+	assert.Equal(t, "*2. lara* 1 point\nXs: 1 · :turkey:: 6", blocks[2].(*slack.SectionBlock).Text.Text)
+
+	// Too many players to fit in one message
+	crowd := []leaderboardRow{}
+	for i := 0; i < messageMaxBlocks; i++ {
+		crowd = append(crowd, leaderboardRow{player: fmt.Sprintf("player%d", i), score: 1, counts: []int{0, 0, 0, 0, 0, 0, 1, 0}})
+	}
+	blocks = leaderboardBlocks("Weekly Wordle Leaderboard", wordle{}.Buckets(), crowd, ":turkey: grandma forgot to show up!")
+	assert.Len(t, blocks, messageMaxBlocks)
+	assert.Equal(t, "…and 3 more", blocks[len(blocks)-2].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.TextBlockObject).Text)
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// ChatConnection is how the bot talks to people, whichever chat platform
+// they're on. Users, channels and messages are identified by the
+// platform's own ids.
+type ChatConnection interface {
+	NameForUser(userId string) (string, error)
+	PostMessage(channel, msg string) error
+	// PostRichMessage posts msg, as Block Kit blocks where the platform has
+	// them and as msg.text otherwise, and returns the new message's ts
+	PostRichMessage(channel string, msg RichMessage) (string, error)
+	// UpdateRichMessage replaces the message at ts
+	UpdateRichMessage(channel, ts string, msg RichMessage) error
+	// PostThreadReply replies in the thread started by the message at threadTs
+	PostThreadReply(channel, threadTs, msg string) error
+	GetUsers(channel string) ([]string, error)
+	// IsAdmin reports whether a user can change the bot's settings for the
+	// channel
+	IsAdmin(channel, userId string) (bool, error)
+	// BotUserID returns the bot's own user id, for spotting @mentions of it
+	BotUserID() (string, error)
+}
+
+// memberCache is a ChatConnection that caches who's in each channel, and
+// needs telling when that changes
+type memberCache interface {
+	forgetMembers(channel string)
+}
+
+// membersCache keeps each channel's members until they expire. Only one
+// lookup of a channel's members runs at a time, and callers who want them
+// meanwhile wait for its answer.
+type membersCache struct {
+	mu      sync.Mutex
+	members map[string]channelMembers
+	// fetching holds the lookups of channels' members that are under way
+	fetching map[string]*membersFetch
+}
+
+// channelMembers are the people in a channel when it was looked up
+type channelMembers struct {
+	userIds []string
+	expires time.Time
+}
+
+// membersFetch is a lookup of a channel's members. done is closed when it
+// has an answer.
+type membersFetch struct {
+	done    chan struct{}
+	userIds []string
+	err     error
+}
+
+// get returns a channel's members, looking them up with fetch if they
+// aren't cached or are older than ttl
+func (c *membersCache) get(channel string, now func() time.Time, ttl time.Duration, fetch func(channel string) ([]string, error)) ([]string, error) {
+	c.mu.Lock()
+	if cached, ok := c.members[channel]; ok && now().Before(cached.expires) {
+		c.mu.Unlock()
+		return slices.Clone(cached.userIds), nil
+	}
+	f, ok := c.fetching[channel]
+	if ok {
+		c.mu.Unlock()
+		<-f.done
+	} else {
+		f = &membersFetch{done: make(chan struct{})}
+		if c.fetching == nil {
+			c.fetching = make(map[string]*membersFetch)
+		}
+		c.fetching[channel] = f
+		c.mu.Unlock()
+
+		// Don't hold the lock over the network, which would hold up every
+		// other channel too
+		f.userIds, f.err = fetch(channel)
+
+		c.mu.Lock()
+		// Unless someone joined or left meanwhile, so it's out of date
+		if c.fetching[channel] == f {
+			delete(c.fetching, channel)
+			if f.err == nil {
+				if c.members == nil {
+					c.members = make(map[string]channelMembers)
+				}
+				c.members[channel] = channelMembers{userIds: f.userIds, expires: now().Add(ttl)}
+			}
+		}
+		c.mu.Unlock()
+		close(f.done)
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return slices.Clone(f.userIds), nil
+}
+
+// forget drops a channel's cached members, and any lookup of them under way
+func (c *membersCache) forget(channel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.members, channel)
+	delete(c.fetching, channel)
+}
+
+// ChatMessage is a message someone posted, on any platform
+type ChatMessage struct {
+	// team is the workspace (Slack) or server (Discord)
+	team    string
+	channel string
+	user    string
+	text    string
+	// ts identifies the message, for replying in a thread
+	ts string
+	// postedAt is when the message was sent, or zero when that's unknown,
+	// as for slash commands
+	postedAt time.Time
+}
+
+func (sm ChatMessage) scope() scope {
+	return scope{team: sm.team, channel: sm.channel}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time, so deadlines and special days can be tested
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the wall clock in DefaultLocation
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return NowDefault()
+}
+
+// fakeClock only moves when told to
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func (c *fakeClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
@@ -0,0 +1,515 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// botName is how people address the bot in a message, besides @mentioning it
+const botName = "WordleTurtle"
+
+// permission is who may run a command
+type permission int
+
+const (
+	permissionEveryone permission = iota
+	permissionAdmin
+)
+
+// argKind is what an argument can be
+type argKind int
+
+const (
+	// argWord is any word
+	argWord argKind = iota
+	// argUser is an @mention, and gives the user's id
+	argUser
+	// argGame is the name of a game, and gives its name as registered
+	argGame
+)
+
+// argSpec declares one of a command's arguments. Users and games are
+// recognized wherever they appear, and words fill the other arguments in
+// order.
+type argSpec struct {
+	name string
+	kind argKind
+	// hint describes the values in help, e.g. "week|month"
+	hint     string
+	optional bool
+	// repeated takes every remaining word
+	repeated bool
+}
+
+func (a argSpec) usage() string {
+	usage := a.hint
+	if usage == "" {
+		usage = a.name
+	}
+	if a.kind == argUser {
+		usage = "@" + usage
+	}
+	if a.repeated {
+		usage += "..."
+	}
+	if a.optional {
+		usage = "[" + usage + "]"
+	}
+	return usage
+}
+
+// commandArgs are a command's parsed arguments, by name
+type commandArgs map[string][]string
+
+func (a commandArgs) get(name string) string {
+	if values := a[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// game is the "game" argument, defaulting to Wordle
+func (a commandArgs) game() Game {
+	if name := a.get("game"); name != "" {
+		return gameByName(name)
+	}
+	return wordle{}
+}
+
+// user is the "user" argument, defaulting to whoever sent the command
+func (a commandArgs) user(sender string) string {
+	if user := a.get("user"); user != "" {
+		return user
+	}
+	return sender
+}
+
+// parseMention returns the user ID from a Slack mention like <@U123|sean>
+func parseMention(arg string) (string, bool) {
+	matcher := regexp.MustCompile(`^<@([^>|]+)(?:\|[^>]*)?>$`)
+	matches := matcher.FindStringSubmatch(arg)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// Command is something people can ask the bot to do
+type Command struct {
+	name       string
+	aliases    []string
+	args       []argSpec
+	permission permission
+	help       string
+	run        func(h *HTTPHandler, sm ChatMessage, args commandArgs) error
+}
+
+func (c Command) usage() string {
+	usage := c.name
+	for _, arg := range c.args {
+		usage += " " + arg.usage()
+	}
+	return usage
+}
+
+// parseArgs matches the words after a command to its arguments
+func (c Command) parseArgs(fields []string) (commandArgs, error) {
+	args := commandArgs{}
+	filled := make([]bool, len(c.args))
+	for _, field := range fields {
+		i, value := c.matchArg(filled, field)
+		if i < 0 {
+			return nil, fmt.Errorf("I don't understand %q. Try %s %s", field, botName, c.usage())
+		}
+		args[c.args[i].name] = append(args[c.args[i].name], value)
+		filled[i] = !c.args[i].repeated
+	}
+	for _, spec := range c.args {
+		if !spec.optional && len(args[spec.name]) == 0 {
+			return nil, fmt.Errorf("%s needs a %s. Try %s %s", c.name, spec.name, botName, c.usage())
+		}
+	}
+	return args, nil
+}
+
+// matchArg finds the argument a word fills, preferring the ones with a type
+// it matches, and returns its index and value
+func (c Command) matchArg(filled []bool, field string) (int, string) {
+	for i, spec := range c.args {
+		if filled[i] {
+			continue
+		}
+		switch spec.kind {
+		case argUser:
+			if user, ok := parseMention(field); ok {
+				return i, user
+			}
+		case argGame:
+			if game := gameByName(field); game != nil {
+				return i, game.Name()
+			}
+		}
+	}
+	for i, spec := range c.args {
+		if !filled[i] && spec.kind == argWord {
+			return i, field
+		}
+	}
+	return -1, ""
+}
+
+// commands holds the registered commands in the order help lists them
+var commands []Command
+
+func registerCommand(c Command) {
+	commands = append(commands, c)
+}
+
+func commandByName(name string) *Command {
+	for i, c := range commands {
+		if strings.EqualFold(c.name, name) {
+			return &commands[i]
+		}
+		for _, alias := range c.aliases {
+			if strings.EqualFold(alias, name) {
+				return &commands[i]
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerCommand(Command{
+		name: "help",
+		help: "display this help text",
+		run:  (*HTTPHandler).handleHelp,
+	})
+	registerCommand(Command{
+		name:    "leaderboard",
+		aliases: []string{"lb", "leaders"},
+		args: []argSpec{
+			{name: "period", hint: "week|month|year|all|<from>..<to>", optional: true},
+			{name: "game", kind: argGame, optional: true},
+		},
+		help: "display a leaderboard (this week's Wordle by default)",
+		run:  (*HTTPHandler).handleLeaderboard,
+	})
+	registerCommand(Command{
+		name:    "streak",
+		aliases: []string{"streaks"},
+		args:    []argSpec{{name: "user", kind: argUser, optional: true}, {name: "game", kind: argGame, optional: true}},
+		help:    "display daily play streaks",
+		run:     (*HTTPHandler).handleStreak,
+	})
+	registerCommand(Command{
+		name:    "stats",
+		aliases: []string{"statistics"},
+		args:    []argSpec{{name: "user", kind: argUser, optional: true}, {name: "game", kind: argGame, optional: true}},
+		help:    "display personal stats and guess distribution",
+		run:     (*HTTPHandler).handleStats,
+	})
+	registerCommand(Command{
+		name:    "ratings",
+		aliases: []string{"rating", "elo"},
+		args:    []argSpec{{name: "game", kind: argGame, optional: true}},
+		help:    "display everyone's skill rating",
+		run:     (*HTTPHandler).handleRatings,
+	})
+	registerCommand(Command{
+		name: "settings",
+		args: []argSpec{
+			{name: "setting", hint: "timezone|deadline|reminders|grace", optional: true},
+			{name: "value", optional: true, repeated: true},
+		},
+		help: "show when the day ends, or (admins only) change it",
+		run:  (*HTTPHandler).handleSettings,
+	})
+	registerCommand(Command{
+		name:       "rejections",
+		permission: permissionAdmin,
+		help:       "list results that didn't count",
+		run:        (*HTTPHandler).handleRejections,
+	})
+	registerCommand(Command{
+		name: "override",
+		args: []argSpec{
+			{name: "user", kind: argUser},
+			{name: "puzzle"},
+			{name: "score"},
+			{name: "game", kind: argGame, optional: true},
+		},
+		permission: permissionAdmin,
+		help:       "change someone's score",
+		run:        (*HTTPHandler).handleOverride,
+	})
+	registerCommand(Command{
+		name:    "delete",
+		aliases: []string{"remove"},
+		args: []argSpec{
+			{name: "user", kind: argUser},
+			{name: "puzzle"},
+			{name: "game", kind: argGame, optional: true},
+		},
+		permission: permissionAdmin,
+		help:       "delete someone's result",
+		run:        (*HTTPHandler).handleDelete,
+	})
+	registerCommand(Command{
+		name: "backfill",
+		args: []argSpec{
+			{name: "user", kind: argUser},
+			{name: "puzzle"},
+			{name: "score"},
+			{name: "game", kind: argGame, optional: true},
+		},
+		permission: permissionAdmin,
+		help:       "add a result someone missed, for today or an earlier puzzle",
+		run:        (*HTTPHandler).handleBackfill,
+	})
+	registerCommand(Command{
+		name: "admins",
+		args: []argSpec{
+			{name: "change", hint: "add|remove", optional: true},
+			{name: "user", kind: argUser, optional: true},
+		},
+		help: "list the channel's admins, or (admins only) change them",
+		run:  (*HTTPHandler).handleAdmins,
+	})
+	registerCommand(Command{
+		name:    "vacation",
+		aliases: []string{"away"},
+		args:    []argSpec{{name: "until", hint: "YYYY-MM-DD|<days>d|<weeks>w"}, {name: "user", kind: argUser, optional: true}},
+		help:    "stop being counted as missing until after a date",
+		run:     (*HTTPHandler).handleVacation,
+	})
+	registerCommand(Command{
+		name:    "spectate",
+		aliases: []string{"spectator"},
+		args:    []argSpec{{name: "user", kind: argUser, optional: true}},
+		help:    "watch without being expected to play",
+		run:     (*HTTPHandler).handleSpectate,
+	})
+	registerCommand(Command{
+		name: "optout",
+		args: []argSpec{{name: "user", kind: argUser, optional: true}},
+		help: "stop having results counted",
+		run:  (*HTTPHandler).handleOptOut,
+	})
+	registerCommand(Command{
+		name:    "optin",
+		aliases: []string{"back"},
+		args:    []argSpec{{name: "user", kind: argUser, optional: true}},
+		help:    "play every day again",
+		run:     (*HTTPHandler).handleOptIn,
+	})
+	registerCommand(Command{
+		name: "players",
+		help: "list who's on vacation, spectating or opted out",
+		run:  (*HTTPHandler).handlePlayers,
+	})
+	registerCommand(Command{
+		name:       "audit",
+		permission: permissionAdmin,
+		help:       "list recent changes admins made to results",
+		run:        (*HTTPHandler).handleAudit,
+	})
+}
+
+// commandText strips the bot's name or @mention off the start of a message
+// addressed to it, returning false for messages that aren't. People talk
+// about the bot by name too, so a message starting with its name only counts
+// when a command, or something close enough to one to suggest it, follows,
+// while an @mention always does.
+func (h *HTTPHandler) commandText(text string) (string, bool, error) {
+	text = strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(text, botName); ok && (rest == "" || rest[0] == ' ') {
+		fields := strings.Fields(rest)
+		if len(fields) == 0 || (commandByName(fields[0]) == nil && !isCommandTypo(fields[0])) {
+			return "", false, nil
+		}
+		return rest, true, nil
+	}
+	if !strings.HasPrefix(text, "<@") {
+		return "", false, nil
+	}
+	mention, rest, _ := strings.Cut(text, " ")
+	user, ok := parseMention(mention)
+	if !ok {
+		return "", false, nil
+	}
+	botID, err := h.chat.BotUserID()
+	if err != nil || user != botID {
+		return "", false, err
+	}
+	return rest, true, nil
+}
+
+// handleCommand runs a command, checking who sent it and its arguments
+func (h *HTTPHandler) handleCommand(sm ChatMessage, name string, fields []string) error {
+	c := commandByName(name)
+	if c == nil {
+		msg := fmt.Sprintf("I don't know the command %q.", name)
+		if suggestion := suggestCommand(name); suggestion != "" {
+			msg += fmt.Sprintf(" Did you mean %s?", suggestion)
+		} else {
+			msg += fmt.Sprintf(" Try %s help", botName)
+		}
+		return h.chat.PostMessage(sm.channel, msg)
+	}
+
+	if c.permission == permissionAdmin {
+		admin, err := h.isAdmin(sm.scope(), sm.user)
+		if err != nil {
+			return err
+		}
+		if !admin {
+			return h.chat.PostMessage(sm.channel, fmt.Sprintf("Only admins can use %s", c.name))
+		}
+	}
+
+	args, err := c.parseArgs(fields)
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	return c.run(h, sm, args)
+}
+
+// isCommandTypo is true for a word close enough to a command to be a typo of
+// it. Short words must be closer, so ones like "is" aren't taken for "lb".
+func isCommandTypo(word string) bool {
+	suggestion := suggestCommand(word)
+	return suggestion != "" && 2*editDistance(strings.ToLower(word), suggestion) < len(word)
+}
+
+// suggestCommand returns the command or alias closest to a mistyped one,
+// if any is close enough
+func suggestCommand(name string) string {
+	// Names before aliases, so they win ties
+	candidates := []string{}
+	for _, c := range commands {
+		candidates = append(candidates, c.name)
+	}
+	for _, c := range commands {
+		candidates = append(candidates, c.aliases...)
+	}
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(name), candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
+
+func (h *HTTPHandler) handleHelp(sm ChatMessage, args commandArgs) error {
+	help := "Supported commands are:"
+	for _, c := range commands {
+		help += fmt.Sprintf("\n%s - %s", c.usage(), c.help)
+		if c.permission == permissionAdmin {
+			help += " (admins only)"
+		}
+		if len(c.aliases) > 0 {
+			help += fmt.Sprintf(" (or %s)", strings.Join(c.aliases, ", "))
+		}
+	}
+	return h.chat.PostMessage(sm.channel, help)
+}
+
+func (h *HTTPHandler) handleLeaderboard(sm ChatMessage, args commandArgs) error {
+	game := args.game()
+	periodArg := args.get("period")
+	if periodArg == "" {
+		periodArg = "week"
+	}
+	wordlenum, err := h.db.getLargestWordle(sm.scope(), game.Name())
+	if err != nil {
+		return err
+	}
+	settings, err := h.db.getChannelSettings(sm.scope())
+	if err != nil {
+		return err
+	}
+	period, err := parseLeaderboardPeriod(settings, game, periodArg, wordlenum)
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	slackPost, err := getLeaderBoardPost(h.db, h.chat, sm.scope(), game, period)
+	if err != nil {
+		return err
+	}
+	_, err = h.chat.PostRichMessage(sm.channel, slackPost)
+	return err
+}
+
+func (h *HTTPHandler) handleStreak(sm ChatMessage, args commandArgs) error {
+	userId, game := args.user(sm.user), args.game()
+	name, err := h.chat.NameForUser(userId)
+	if err != nil {
+		return err
+	}
+	history, err := h.db.getPlayerResults(sm.scope(), game.Name(), userId)
+	if err != nil {
+		return err
+	}
+	settings, err := h.db.getChannelSettings(sm.scope())
+	if err != nil {
+		return err
+	}
+	streaks := computeStreaks(game, history, settings.puzzleToday(game, h.now()))
+	return h.chat.PostMessage(sm.channel, getStreakPost(name, game, streaks))
+}
+
+func (h *HTTPHandler) handleStats(sm ChatMessage, args commandArgs) error {
+	userId, game := args.user(sm.user), args.game()
+	name, err := h.chat.NameForUser(userId)
+	if err != nil {
+		return err
+	}
+	history, err := h.db.getPlayerResults(sm.scope(), game.Name(), userId)
+	if err != nil {
+		return err
+	}
+	everyone, err := h.db.getChannelResults(sm.scope(), game.Name())
+	if err != nil {
+		return err
+	}
+	rank, players := channelRank(game, everyone, userId)
+	return h.chat.PostMessage(sm.channel, getStatsPost(name, game, computeStats(game, history), rank, players))
+}
+
+func (h *HTTPHandler) handleRatings(sm ChatMessage, args commandArgs) error {
+	game := args.game()
+	ratings, err := h.db.getRatings(sm.scope(), game.Name())
+	if err != nil {
+		return err
+	}
+	slackPost, err := getRatingsPost(h.chat, game, ratings)
+	if err != nil {
+		return err
+	}
+	return h.chat.PostMessage(sm.channel, slackPost)
+}
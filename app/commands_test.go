@@ -0,0 +1,99 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseMention(t *testing.T) {
+	user, ok := parseMention("<@U0123ABC>")
+	assert.True(t, ok)
+	assert.Equal(t, "U0123ABC", user)
+
+	user, ok = parseMention("<@U0123ABC|sean>")
+	assert.True(t, ok)
+	assert.Equal(t, "U0123ABC", user)
+
+	_, ok = parseMention("sean")
+	assert.False(t, ok)
+}
+
+func Test_parseArgs(t *testing.T) {
+	streak := commandByName("streak")
+	require.NotNil(t, streak)
+
+	args, err := streak.parseArgs([]string{"connections", "<@U0123ABC|sean>"})
+	require.NoError(t, err)
+	assert.Equal(t, "U0123ABC", args.user("sender"))
+	assert.Equal(t, "Connections", args.game().Name())
+
+	args, err = streak.parseArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "sender", args.user("sender"))
+	assert.Equal(t, "Wordle", args.game().Name())
+
+	_, err = streak.parseArgs([]string{"sean"})
+	assert.EqualError(t, err, `I don't understand "sean". Try WordleTurtle streak [@user] [game]`)
+
+	settings := commandByName("settings")
+	args, err = settings.parseArgs([]string{"reminders", "1h", "15m"})
+	require.NoError(t, err)
+	assert.Equal(t, "reminders", args.get("setting"))
+	assert.Equal(t, []string{"1h", "15m"}, args["value"])
+
+	// Games can come before words
+	args, err = commandByName("lb").parseArgs([]string{"strands", "month"})
+	require.NoError(t, err)
+	assert.Equal(t, "month", args.get("period"))
+	assert.Equal(t, "Strands", args.get("game"))
+}
+
+func Test_suggestCommand(t *testing.T) {
+	assert.Equal(t, "leaderboard", suggestCommand("leaderbord"))
+	assert.Equal(t, "stats", suggestCommand("stat"))
+	assert.Equal(t, "", suggestCommand("xyzzy"))
+
+	assert.True(t, isCommandTypo("leaderbord"))
+	assert.True(t, isCommandTypo("stat"))
+	assert.False(t, isCommandTypo("is"))
+	assert.False(t, isCommandTypo("xyzzy"))
+}
+
+func Test_handlesCommand_Mention(t *testing.T) {
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: new(MockDB), chat: mockSlack}
+
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("BotUserID").Return("UBOT", nil)
+	mockSlack.On("PostMessage", "testchannel", mock.MatchedBy(func(msg string) bool {
+		return strings.HasPrefix(msg, "Supported commands are:")
+	})).Return(nil).Twice()
+	mockSlack.On("PostMessage", "testchannel", `I don't know the command "halp". Did you mean help?`).Return(nil).Once()
+	mockSlack.On("PostMessage", "testchannel", `I don't know the command "leaderbord". Did you mean leaderboard?`).Return(nil).Once()
+
+	sm := ChatMessage{team: "testteam", channel: "testchannel", user: "userid1"}
+	// Only an @mention gets told off for an unknown command, since people
+	// talk about the bot by name, unless it's a typo of one
+	for _, text := range []string{"<@UBOT> help", "<@UBOT>", "<@UBOT> halp", "<@USOMEONE> help", "WordleTurtles help", "WordleTurtle is the best", "WordleTurtle", "WordleTurtle leaderbord"} {
+		sm.text = text
+		assert.Nil(t, h.handleUserMessage(sm))
+	}
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_handleHelp(t *testing.T) {
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{chat: mockSlack}
+
+	mockSlack.On("PostMessage", "testchannel", mock.Anything).Return(nil)
+	assert.Nil(t, h.handleHelp(ChatMessage{channel: "testchannel"}, nil))
+	help := mockSlack.Calls[0].Arguments.String(1)
+	assert.Contains(t, help, "\nleaderboard [week|month|year|all|<from>..<to>] [game] - display a leaderboard (this week's Wordle by default) (or lb, leaders)")
+	assert.Contains(t, help, "\nstreak [@user] [game] - display daily play streaks")
+	assert.Contains(t, help, "\nrejections - list results that didn't count (admins only)")
+	assert.Contains(t, help, "\noverride @user puzzle score [game] - change someone's score (admins only)")
+}
@@ -2,49 +2,602 @@ package app
 
 import (
 	"database/sql"
-
-	_ "github.com/mattn/go-sqlite3"
+	"fmt"
+	"strings"
+	"time"
+	"wordleturtle/config"
 )
 
 type DB interface {
 	putResult(result Result) error
-	getDailyResults(wordlenum int) ([]Result, error)
-	getLargestWordle() (int, error)
+	getDailyResults(sc scope, game string, wordlenum int) ([]Result, error)
+	getLargestWordle(sc scope, game string) (int, error)
+	// getPlayerResults returns every result a player has posted for a game
+	getPlayerResults(sc scope, game string, userId string) ([]Result, error)
+	// getChannelResults returns every result posted for a game in a channel
+	getChannelResults(sc scope, game string) ([]Result, error)
+	// getResultsInRange returns a channel's results for puzzles from..to inclusive
+	getResultsInRange(sc scope, game string, from, to int) ([]Result, error)
+	// getResultByTs returns the result posted in a message, or nil
+	getResultByTs(sc scope, ts string) (*Result, error)
+	// deleteResult removes a player's result for a puzzle
+	deleteResult(result Result) error
+
+	// getRatings returns everyone's rating for a game in a channel
+	getRatings(sc scope, game string) ([]Rating, error)
+	// putRating saves a player's rating, replacing any previous one, and
+	// keeps it in their history as their rating after rating.wordlenum
+	putRating(sc scope, game string, rating Rating) error
+	// getRatingsBefore returns everyone's rating from the last puzzle rated
+	// before wordlenum
+	getRatingsBefore(sc scope, game string, wordlenum int) ([]Rating, error)
+	// getRatedPuzzles returns the puzzles from wordlenum on that were rated,
+	// in order
+	getRatedPuzzles(sc scope, game string, wordlenum int) ([]int, error)
+	// getLastSeededPuzzle returns the last puzzle rated before rating history
+	// was kept, or 0
+	getLastSeededPuzzle(sc scope, game string) (int, error)
+	// deleteRatingsFrom forgets ratings for wordlenum and later puzzles
+	deleteRatingsFrom(sc scope, game string, wordlenum int) error
+
+	// getSummaryTs returns the ts of a puzzle's live summary message, or ""
+	getSummaryTs(sc scope, game string, wordlenum int) (string, error)
+	// putSummaryTs saves the ts of a puzzle's live summary message
+	putSummaryTs(sc scope, game string, wordlenum int, ts string) error
+
+	// putEvent queues an event, returning false if we've seen its id before
+	putEvent(id string, body []byte, receivedAt time.Time) (bool, error)
+	// getQueuedEvents returns the events that aren't done or claimed, oldest
+	// first. Claims older than eventLease have lapsed.
+	getQueuedEvents(now time.Time) ([]QueuedEvent, error)
+	// claimEvent marks an event as taken until its lease lapses, returning
+	// false if someone beat us to it
+	claimEvent(id string, now time.Time) (bool, error)
+	// finishEvent marks an event as done, so it's never processed again
+	finishEvent(id string) error
+	// releaseEvent gives up the claim on an event, so it's retried
+	releaseEvent(id string) error
+	// deleteEventsBefore forgets done events received before t
+	deleteEventsBefore(t time.Time) error
+
+	// putJob saves a scheduled job, doing nothing if it already exists
+	putJob(job Job) error
+	// getDueJobs returns the jobs scheduled at or before now that aren't
+	// done or claimed. Claims older than jobLease have lapsed.
+	getDueJobs(now time.Time) ([]Job, error)
+	// claimJob marks a job as taken until its lease lapses, returning false
+	// if someone beat us to it
+	claimJob(id int64, now time.Time) (bool, error)
+	// finishJob marks a job as done, so it never runs again
+	finishJob(id int64) error
+	// releaseJob gives up the claim on a job, so it's retried
+	releaseJob(id int64) error
+	// getPendingJobs returns a channel's jobs that haven't started
+	getPendingJobs(sc scope) ([]Job, error)
+	// unscheduleJob deletes a job, returning false if it already started
+	unscheduleJob(id int64) (bool, error)
+
+	// getChannelSettings returns a channel's settings, or the defaults
+	getChannelSettings(sc scope) (ChannelSettings, error)
+	// putChannelSettings saves a channel's settings
+	putChannelSettings(sc scope, settings ChannelSettings) error
+
+	// putRejection records a result we refused to count
+	putRejection(rejection Rejection) error
+	// getRejections returns a channel's most recent rejections, newest first
+	getRejections(sc scope, limit int) ([]Rejection, error)
+
+	// getChannelAdmins returns the users made admins of a channel
+	getChannelAdmins(sc scope) ([]string, error)
+	// putChannelAdmin makes a user an admin of a channel
+	putChannelAdmin(sc scope, userId string) error
+	// deleteChannelAdmin stops a user being an admin of a channel
+	deleteChannelAdmin(sc scope, userId string) error
+
+	// putAudit records a change an admin made
+	putAudit(entry AuditEntry) error
+	// getAudit returns a channel's most recent changes, newest first
+	getAudit(sc scope, limit int) ([]AuditEntry, error)
+
+	// getParticipation returns the channel's players who aren't active, by
+	// user id
+	getParticipation(sc scope) (map[string]Participation, error)
+	// putParticipation sets whether a player is active, on vacation, etc.
+	putParticipation(sc scope, userId string, p Participation) error
+}
+
+// NewDB opens the database chosen in the config and applies any pending
+// migrations
+func NewDB(c *config.BotConfig) (DB, error) {
+	params := migrationParamsFromConfig(c)
+	switch c.DBDriver {
+	case config.DBSQLite:
+		return NewSQLiteDB(c.SQLitePath, params)
+	case config.DBPostgres:
+		return NewPostgresDB(c.DatabaseURL, params)
+	}
+	return nil, fmt.Errorf("unknown database driver %q", c.DBDriver)
+}
+
+// dialect holds what differs between the SQL databases we support
+type dialect struct {
+	driver string
+	// migrations is the directory in the database package to migrate from
+	migrations string
+	// placeholder formats the nth (1-indexed) query parameter
+	placeholder func(n int) string
+	// tableExists counts the tables named by its one parameter
+	tableExists string
+}
+
+// sqlDB implements DB for any database/sql database. Queries are written
+// with ? placeholders and rewritten for the dialect.
+type sqlDB struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+func (db *sqlDB) rebind(query string) string {
+	if db.dialect.placeholder == nil {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString(db.dialect.placeholder(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func (db *sqlDB) exec(query string, args ...any) (sql.Result, error) {
+	return db.db.Exec(db.rebind(query), args...)
 }
 
-type SQLiteDB struct {
-	db *sql.DB
+func (db *sqlDB) query(query string, args ...any) (*sql.Rows, error) {
+	return db.db.Query(db.rebind(query), args...)
 }
 
-func NewSQLiteDB(path string) (*SQLiteDB, error) {
-	db, err := sql.Open("sqlite3", path)
-	return &SQLiteDB{db: db}, err
+func (db *sqlDB) queryRow(query string, args ...any) *sql.Row {
+	return db.db.QueryRow(db.rebind(query), args...)
 }
 
-func (db *SQLiteDB) putResult(result Result) error {
-	_, err := db.db.Exec("INSERT INTO results(wordlenum, userId, displayName, score, hardmode) VALUES( ?, ?, ?, ?, ? )", result.wordlenum, result.userId, result.displayName, result.score, result.hardmode)
+func (db *sqlDB) putResult(result Result) error {
+	grid := strings.Join(result.grid, "\n")
+	_, err := db.exec(`INSERT INTO results(team, channel, game, wordlenum, userId, displayName, score, hardmode, grid, ts) VALUES( ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )
+		ON CONFLICT (team, channel, game, wordlenum, userId) DO UPDATE SET displayName=excluded.displayName, score=excluded.score, hardmode=excluded.hardmode, grid=excluded.grid, ts=excluded.ts, timestamp=CURRENT_TIMESTAMP`,
+		result.team, result.channel, result.game, result.wordlenum, result.userId, result.displayName, result.score, result.hardmode, grid, result.ts)
 	return err
 }
 
-func (db *SQLiteDB) getDailyResults(wordlenum int) ([]Result, error) {
-	rows, err := db.db.Query("SELECT wordlenum, userId, displayName, score, hardmode FROM results where wordlenum=?", wordlenum)
+func (db *sqlDB) deleteResult(result Result) error {
+	_, err := db.exec("DELETE FROM results WHERE team=? AND channel=? AND game=? AND wordlenum=? AND userId=?", result.team, result.channel, result.game, result.wordlenum, result.userId)
+	return err
+}
+
+// resultColumns are the columns scanResults expects, in order
+const resultColumns = "team, channel, game, wordlenum, userId, displayName, score, hardmode, COALESCE(grid, ''), COALESCE(ts, '')"
+
+func (db *sqlDB) getDailyResults(sc scope, game string, wordlenum int) ([]Result, error) {
+	rows, err := db.query("SELECT "+resultColumns+" FROM results where team=? AND channel=? AND game=? AND wordlenum=?", sc.team, sc.channel, game, wordlenum)
 	if err != nil {
 		return nil, err
 	}
+	return scanResults(rows)
+}
+
+func (db *sqlDB) getPlayerResults(sc scope, game string, userId string) ([]Result, error) {
+	rows, err := db.query("SELECT "+resultColumns+" FROM results where team=? AND channel=? AND game=? AND userId=? ORDER BY wordlenum", sc.team, sc.channel, game, userId)
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+func (db *sqlDB) getChannelResults(sc scope, game string) ([]Result, error) {
+	rows, err := db.query("SELECT "+resultColumns+" FROM results where team=? AND channel=? AND game=? ORDER BY wordlenum", sc.team, sc.channel, game)
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+func (db *sqlDB) getResultsInRange(sc scope, game string, from, to int) ([]Result, error) {
+	rows, err := db.query("SELECT "+resultColumns+" FROM results where team=? AND channel=? AND game=? AND wordlenum>=? AND wordlenum<=? ORDER BY wordlenum", sc.team, sc.channel, game, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+func (db *sqlDB) getResultByTs(sc scope, ts string) (*Result, error) {
+	rows, err := db.query("SELECT "+resultColumns+" FROM results where team=? AND channel=? AND ts=?", sc.team, sc.channel, ts)
+	if err != nil {
+		return nil, err
+	}
+	results, err := scanResults(rows)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+func scanResults(rows *sql.Rows) ([]Result, error) {
+	defer rows.Close()
 	results := make([]Result, 0)
 	for rows.Next() {
 		var r Result
-		if err := rows.Scan(&r.wordlenum, &r.userId, &r.displayName, &r.score, &r.hardmode); err != nil {
+		var grid string
+		if err := rows.Scan(&r.team, &r.channel, &r.game, &r.wordlenum, &r.userId, &r.displayName, &r.score, &r.hardmode, &grid, &r.ts); err != nil {
 			return nil, err
 		}
+		if grid != "" {
+			r.grid = strings.Split(grid, "\n")
+		}
 		results = append(results, r)
 	}
-	return results, nil
+	return results, rows.Err()
 }
 
-func (db *SQLiteDB) getLargestWordle() (int, error) {
-	row := db.db.QueryRow("SELECT MAX(wordlenum) FROM results")
+func (db *sqlDB) getLargestWordle(sc scope, game string) (int, error) {
+	row := db.queryRow("SELECT COALESCE(MAX(wordlenum), 0) FROM results WHERE team=? AND channel=? AND game=?", sc.team, sc.channel, game)
 	var max int
 	err := row.Scan(&max)
 	return max, err
 }
+
+func (db *sqlDB) getRatings(sc scope, game string) ([]Rating, error) {
+	rows, err := db.query("SELECT userId, rating, rd, volatility, wordlenum FROM ratings WHERE team=? AND channel=? AND game=? ORDER BY rating DESC", sc.team, sc.channel, game)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ratings := make([]Rating, 0)
+	for rows.Next() {
+		var r Rating
+		if err := rows.Scan(&r.userId, &r.rating, &r.rd, &r.volatility, &r.wordlenum); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, rows.Err()
+}
+
+func (db *sqlDB) putRating(sc scope, game string, r Rating) error {
+	_, err := db.exec(`INSERT INTO ratings(team, channel, game, userId, rating, rd, volatility, wordlenum) VALUES( ?, ?, ?, ?, ?, ?, ?, ? )
+		ON CONFLICT (team, channel, game, userId) DO UPDATE SET rating=excluded.rating, rd=excluded.rd, volatility=excluded.volatility, wordlenum=excluded.wordlenum`,
+		sc.team, sc.channel, game, r.userId, r.rating, r.rd, r.volatility, r.wordlenum)
+	if err != nil {
+		return err
+	}
+	_, err = db.exec(`INSERT INTO rating_history(team, channel, game, userId, wordlenum, rating, rd, volatility) VALUES( ?, ?, ?, ?, ?, ?, ?, ? )
+		ON CONFLICT (team, channel, game, userId, wordlenum) DO UPDATE SET rating=excluded.rating, rd=excluded.rd, volatility=excluded.volatility`,
+		sc.team, sc.channel, game, r.userId, r.wordlenum, r.rating, r.rd, r.volatility)
+	return err
+}
+
+func (db *sqlDB) getRatingsBefore(sc scope, game string, wordlenum int) ([]Rating, error) {
+	rows, err := db.query(`SELECT userId, rating, rd, volatility, wordlenum FROM rating_history WHERE team=? AND channel=? AND game=?
+		AND wordlenum=(SELECT MAX(wordlenum) FROM rating_history WHERE team=? AND channel=? AND game=? AND wordlenum<?)`,
+		sc.team, sc.channel, game, sc.team, sc.channel, game, wordlenum)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ratings := make([]Rating, 0)
+	for rows.Next() {
+		var r Rating
+		if err := rows.Scan(&r.userId, &r.rating, &r.rd, &r.volatility, &r.wordlenum); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, rows.Err()
+}
+
+func (db *sqlDB) getRatedPuzzles(sc scope, game string, wordlenum int) ([]int, error) {
+	rows, err := db.query("SELECT DISTINCT wordlenum FROM rating_history WHERE team=? AND channel=? AND game=? AND wordlenum>=? ORDER BY wordlenum",
+		sc.team, sc.channel, game, wordlenum)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	puzzles := make([]int, 0)
+	for rows.Next() {
+		var num int
+		if err := rows.Scan(&num); err != nil {
+			return nil, err
+		}
+		puzzles = append(puzzles, num)
+	}
+	return puzzles, rows.Err()
+}
+
+func (db *sqlDB) getLastSeededPuzzle(sc scope, game string) (int, error) {
+	row := db.queryRow("SELECT COALESCE(MAX(wordlenum), 0) FROM rating_history WHERE team=? AND channel=? AND game=? AND seeded=1", sc.team, sc.channel, game)
+	var last int
+	err := row.Scan(&last)
+	return last, err
+}
+
+func (db *sqlDB) deleteRatingsFrom(sc scope, game string, wordlenum int) error {
+	if _, err := db.exec("DELETE FROM rating_history WHERE team=? AND channel=? AND game=? AND wordlenum>=?", sc.team, sc.channel, game, wordlenum); err != nil {
+		return err
+	}
+	_, err := db.exec("DELETE FROM ratings WHERE team=? AND channel=? AND game=? AND wordlenum>=?", sc.team, sc.channel, game, wordlenum)
+	return err
+}
+
+func (db *sqlDB) getSummaryTs(sc scope, game string, wordlenum int) (string, error) {
+	row := db.queryRow("SELECT ts FROM summaries WHERE team=? AND channel=? AND game=? AND wordlenum=?", sc.team, sc.channel, game, wordlenum)
+	var ts string
+	err := row.Scan(&ts)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return ts, err
+}
+
+func (db *sqlDB) putSummaryTs(sc scope, game string, wordlenum int, ts string) error {
+	_, err := db.exec("INSERT INTO summaries(team, channel, game, wordlenum, ts) VALUES( ?, ?, ?, ?, ? ) ON CONFLICT (team, channel, game, wordlenum) DO UPDATE SET ts=excluded.ts",
+		sc.team, sc.channel, game, wordlenum, ts)
+	return err
+}
+
+func (db *sqlDB) putJob(job Job) error {
+	_, err := db.exec("INSERT INTO jobs(kind, team, channel, game, wordlenum, runAt) VALUES( ?, ?, ?, ?, ?, ? ) ON CONFLICT DO NOTHING", job.kind, job.team, job.channel, job.game, job.wordlenum, job.runAt.Unix())
+	return err
+}
+
+func (db *sqlDB) getDueJobs(now time.Time) ([]Job, error) {
+	rows, err := db.query("SELECT "+jobColumns+" FROM jobs WHERE done=0 AND runAt<=? AND claimedAt<? ORDER BY runAt", now.Unix(), now.Add(-jobLease).Unix())
+	if err != nil {
+		return nil, err
+	}
+	return scanJobs(rows)
+}
+
+func (db *sqlDB) getPendingJobs(sc scope) ([]Job, error) {
+	rows, err := db.query("SELECT "+jobColumns+" FROM jobs WHERE done=0 AND claimedAt=0 AND team=? AND channel=? ORDER BY runAt", sc.team, sc.channel)
+	if err != nil {
+		return nil, err
+	}
+	return scanJobs(rows)
+}
+
+// jobColumns are the columns scanJobs expects, in order
+const jobColumns = "id, kind, team, channel, game, wordlenum, runAt, attempts"
+
+func scanJobs(rows *sql.Rows) ([]Job, error) {
+	defer rows.Close()
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		var j Job
+		var runAt int64
+		if err := rows.Scan(&j.id, &j.kind, &j.team, &j.channel, &j.game, &j.wordlenum, &runAt, &j.attempts); err != nil {
+			return nil, err
+		}
+		j.runAt = time.Unix(runAt, 0).In(DefaultLocation())
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (db *sqlDB) claimJob(id int64, now time.Time) (bool, error) {
+	res, err := db.exec("UPDATE jobs SET claimedAt=?, attempts=attempts+1 WHERE id=? AND done=0 AND claimedAt<?", now.Unix(), id, now.Add(-jobLease).Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (db *sqlDB) finishJob(id int64) error {
+	_, err := db.exec("UPDATE jobs SET done=1 WHERE id=?", id)
+	return err
+}
+
+func (db *sqlDB) releaseJob(id int64) error {
+	_, err := db.exec("UPDATE jobs SET claimedAt=0 WHERE id=? AND done=0", id)
+	return err
+}
+
+func (db *sqlDB) unscheduleJob(id int64) (bool, error) {
+	res, err := db.exec("DELETE FROM jobs WHERE id=? AND done=0 AND claimedAt=0", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (db *sqlDB) getChannelSettings(sc scope) (ChannelSettings, error) {
+	row := db.queryRow("SELECT timezone, deadline, reminders, grace FROM channel_settings WHERE team=? AND channel=?", sc.team, sc.channel)
+	var timezone, reminders string
+	var grace int
+	settings := defaultChannelSettings()
+	err := row.Scan(&timezone, &settings.deadline, &reminders, &grace)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if settings.location, err = time.LoadLocation(timezone); err != nil {
+		return settings, err
+	}
+	settings.grace = time.Duration(grace) * time.Minute
+	settings.reminders, err = decodeReminders(reminders)
+	return settings, err
+}
+
+func (db *sqlDB) putChannelSettings(sc scope, settings ChannelSettings) error {
+	_, err := db.exec(`INSERT INTO channel_settings(team, channel, timezone, deadline, reminders, grace) VALUES( ?, ?, ?, ?, ?, ? )
+		ON CONFLICT (team, channel) DO UPDATE SET timezone=excluded.timezone, deadline=excluded.deadline, reminders=excluded.reminders, grace=excluded.grace`,
+		sc.team, sc.channel, settings.location.String(), settings.deadline, encodeReminders(settings.reminders), int(settings.grace.Minutes()))
+	return err
+}
+
+func (db *sqlDB) putRejection(r Rejection) error {
+	_, err := db.exec("INSERT INTO rejections(team, channel, game, wordlenum, userId, displayName, score, ts, reason, rejectedAt) VALUES( ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )",
+		r.result.team, r.result.channel, r.result.game, r.result.wordlenum, r.result.userId, r.result.displayName, r.result.score, r.result.ts, r.reason, r.rejectedAt.Unix())
+	return err
+}
+
+func (db *sqlDB) getRejections(sc scope, limit int) ([]Rejection, error) {
+	rows, err := db.query("SELECT team, channel, game, wordlenum, userId, displayName, score, ts, reason, rejectedAt FROM rejections WHERE team=? AND channel=? ORDER BY rejectedAt DESC, id DESC LIMIT ?", sc.team, sc.channel, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rejections := make([]Rejection, 0)
+	for rows.Next() {
+		var r Rejection
+		var rejectedAt int64
+		if err := rows.Scan(&r.result.team, &r.result.channel, &r.result.game, &r.result.wordlenum, &r.result.userId, &r.result.displayName, &r.result.score, &r.result.ts, &r.reason, &rejectedAt); err != nil {
+			return nil, err
+		}
+		r.rejectedAt = time.Unix(rejectedAt, 0).In(DefaultLocation())
+		rejections = append(rejections, r)
+	}
+	return rejections, rows.Err()
+}
+
+func (db *sqlDB) putEvent(id string, body []byte, receivedAt time.Time) (bool, error) {
+	res, err := db.exec("INSERT INTO events(id, body, receivedAt) VALUES( ?, ?, ? ) ON CONFLICT DO NOTHING", id, string(body), receivedAt.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (db *sqlDB) getQueuedEvents(now time.Time) ([]QueuedEvent, error) {
+	rows, err := db.query("SELECT id, body, receivedAt, attempts FROM events WHERE done=0 AND claimedAt<? ORDER BY receivedAt", now.Add(-eventLease).Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := make([]QueuedEvent, 0)
+	for rows.Next() {
+		var e QueuedEvent
+		var body string
+		var receivedAt int64
+		if err := rows.Scan(&e.id, &body, &receivedAt, &e.attempts); err != nil {
+			return nil, err
+		}
+		e.body = []byte(body)
+		e.receivedAt = time.Unix(receivedAt, 0).In(DefaultLocation())
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (db *sqlDB) claimEvent(id string, now time.Time) (bool, error) {
+	res, err := db.exec("UPDATE events SET claimedAt=?, attempts=attempts+1 WHERE id=? AND done=0 AND claimedAt<?", now.Unix(), id, now.Add(-eventLease).Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (db *sqlDB) finishEvent(id string) error {
+	_, err := db.exec("UPDATE events SET done=1 WHERE id=?", id)
+	return err
+}
+
+func (db *sqlDB) releaseEvent(id string) error {
+	_, err := db.exec("UPDATE events SET claimedAt=0 WHERE id=? AND done=0", id)
+	return err
+}
+
+func (db *sqlDB) deleteEventsBefore(t time.Time) error {
+	_, err := db.exec("DELETE FROM events WHERE done=1 AND receivedAt<?", t.Unix())
+	return err
+}
+
+func (db *sqlDB) getChannelAdmins(sc scope) ([]string, error) {
+	rows, err := db.query("SELECT userId FROM channel_admins WHERE team=? AND channel=? ORDER BY userId", sc.team, sc.channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	admins := make([]string, 0)
+	for rows.Next() {
+		var userId string
+		if err := rows.Scan(&userId); err != nil {
+			return nil, err
+		}
+		admins = append(admins, userId)
+	}
+	return admins, rows.Err()
+}
+
+func (db *sqlDB) putChannelAdmin(sc scope, userId string) error {
+	_, err := db.exec("INSERT INTO channel_admins(team, channel, userId) VALUES( ?, ?, ? ) ON CONFLICT DO NOTHING", sc.team, sc.channel, userId)
+	return err
+}
+
+func (db *sqlDB) deleteChannelAdmin(sc scope, userId string) error {
+	_, err := db.exec("DELETE FROM channel_admins WHERE team=? AND channel=? AND userId=?", sc.team, sc.channel, userId)
+	return err
+}
+
+func (db *sqlDB) putAudit(e AuditEntry) error {
+	_, err := db.exec("INSERT INTO audit_log(team, channel, adminId, action, game, wordlenum, userId, oldScore, newScore, changedAt) VALUES( ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )",
+		e.team, e.channel, e.adminId, e.action, e.game, e.wordlenum, e.userId, e.oldScore, e.newScore, e.changedAt.Unix())
+	return err
+}
+
+func (db *sqlDB) getAudit(sc scope, limit int) ([]AuditEntry, error) {
+	rows, err := db.query("SELECT team, channel, adminId, action, game, wordlenum, userId, oldScore, newScore, changedAt FROM audit_log WHERE team=? AND channel=? ORDER BY changedAt DESC, id DESC LIMIT ?", sc.team, sc.channel, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var e AuditEntry
+		var changedAt int64
+		if err := rows.Scan(&e.team, &e.channel, &e.adminId, &e.action, &e.game, &e.wordlenum, &e.userId, &e.oldScore, &e.newScore, &changedAt); err != nil {
+			return nil, err
+		}
+		e.changedAt = time.Unix(changedAt, 0).In(DefaultLocation())
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (db *sqlDB) getParticipation(sc scope) (map[string]Participation, error) {
+	rows, err := db.query("SELECT userId, state, COALESCE(awayFrom, ''), COALESCE(awayUntil, '') FROM participation WHERE team=? AND channel=?", sc.team, sc.channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	players := make(map[string]Participation)
+	for rows.Next() {
+		var userId string
+		var p Participation
+		if err := rows.Scan(&userId, &p.state, &p.from, &p.until); err != nil {
+			return nil, err
+		}
+		players[userId] = p
+	}
+	return players, rows.Err()
+}
+
+func (db *sqlDB) putParticipation(sc scope, userId string, p Participation) error {
+	if p.state == stateActive {
+		_, err := db.exec("DELETE FROM participation WHERE team=? AND channel=? AND userId=?", sc.team, sc.channel, userId)
+		return err
+	}
+	_, err := db.exec(`INSERT INTO participation(team, channel, userId, state, awayFrom, awayUntil) VALUES( ?, ?, ?, ?, ?, ? )
+		ON CONFLICT (team, channel, userId) DO UPDATE SET state=excluded.state, awayFrom=excluded.awayFrom, awayUntil=excluded.awayUntil`,
+		sc.team, sc.channel, userId, p.state, p.from, p.until)
+	return err
+}
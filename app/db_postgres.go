@@ -0,0 +1,50 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/lib/pq"
+)
+
+var postgresDialect = dialect{
+	driver:      "postgres",
+	migrations:  "postgres",
+	placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	tableExists: "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema=current_schema() AND table_name=?",
+}
+
+// PostgresDB keeps results in Postgres, for deployments (like Lambda) with
+// nowhere durable to keep a SQLite file
+type PostgresDB struct {
+	sqlDB
+}
+
+// NewPostgresDB connects to the database at dsn and applies any pending
+// migrations
+func NewPostgresDB(dsn string, params MigrationParams) (*PostgresDB, error) {
+	db, err := openPostgresDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+	m, err := db.migrator(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.up(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func openPostgresDB(dsn string) (*PostgresDB, error) {
+	db, err := sql.Open(postgresDialect.driver, dsn)
+	return &PostgresDB{sqlDB{db: db, dialect: postgresDialect}}, err
+}
+
+func (db *PostgresDB) migrator(params MigrationParams) (*migrator, error) {
+	// Postgres support arrived with migrations, so there are no hand-built
+	// databases to account for
+	legacyVersion := func() (int, error) { return 0, nil }
+	return newMigrator(&db.sqlDB, params, legacyVersion)
+}
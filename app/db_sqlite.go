@@ -0,0 +1,80 @@
+package app
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var sqliteDialect = dialect{
+	driver:      "sqlite3",
+	migrations:  "sqlite",
+	tableExists: "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?",
+}
+
+type SQLiteDB struct {
+	sqlDB
+}
+
+// NewSQLiteDB opens the database at path and applies any pending migrations
+func NewSQLiteDB(path string, params MigrationParams) (*SQLiteDB, error) {
+	db, err := openSQLiteDB(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := db.migrator(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.up(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func openSQLiteDB(path string) (*SQLiteDB, error) {
+	db, err := sql.Open(sqliteDialect.driver, path)
+	return &SQLiteDB{sqlDB{db: db, dialect: sqliteDialect}}, err
+}
+
+func (db *SQLiteDB) migrator(params MigrationParams) (*migrator, error) {
+	return newMigrator(&db.sqlDB, params, db.legacyVersion)
+}
+
+// legacyVersion works out which migrations a database built by hand from
+// the old init scripts already has
+func (db *SQLiteDB) legacyVersion() (int, error) {
+	has := func(query string, args ...any) (bool, error) {
+		var count int
+		err := db.queryRow(query, args...).Scan(&count)
+		return count > 0, err
+	}
+	hasTable := func(table string) (bool, error) {
+		return has(db.dialect.tableExists, table)
+	}
+	hasColumn := func(table, column string) (bool, error) {
+		return has("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?", table, column)
+	}
+
+	// Newest first, each implies the ones before it
+	checks := []struct {
+		version int
+		check   func() (bool, error)
+	}{
+		{5, func() (bool, error) { return hasColumn("results", "team") }},
+		{4, func() (bool, error) { return hasTable("jobs") }},
+		{3, func() (bool, error) { return hasColumn("results", "game") }},
+		{2, func() (bool, error) { return hasColumn("results", "grid") }},
+		{1, func() (bool, error) { return hasTable("results") }},
+	}
+	for _, c := range checks {
+		ok, err := c.check()
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return c.version, nil
+		}
+	}
+	return 0, nil
+}
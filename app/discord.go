@@ -0,0 +1,625 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wordleturtle/config"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	discordAPIURL     = "https://discord.com/api/v10"
+	discordGatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+	// discordMaxMessage is the most characters Discord accepts in a message
+	discordMaxMessage = 2000
+	// discordMaxMembers is the page size for listing a server's members
+	discordMaxMembers = 1000
+	// discordReconnectDelay is how long to wait before reconnecting to the gateway
+	discordReconnectDelay = 5 * time.Second
+	// discordEpoch is the unix time in milliseconds that snowflake ids count
+	// from
+	discordEpoch = 1420070400000
+
+	// Gateway intents. Server members and message content are privileged,
+	// and have to be switched on for the bot in the developer portal.
+	discordIntentGuilds         = 1 << 0
+	discordIntentGuildMembers   = 1 << 1
+	discordIntentGuildMessages  = 1 << 9
+	discordIntentMessageContent = 1 << 15
+
+	discordPermissionAdministrator = 1 << 3
+	discordPermissionManageGuild   = 1 << 5
+	discordPermissionViewChannel   = 1 << 10
+
+	// discordOverwriteMember marks a channel's permission overwrite for a
+	// member, rather than a role
+	discordOverwriteMember = 1
+
+	// discordEventPrefix starts the ids of gateway events in the event queue
+	discordEventPrefix = "discord:"
+
+	// Gateway opcodes
+	discordOpDispatch       = 0
+	discordOpHeartbeat      = 1
+	discordOpIdentify       = 2
+	discordOpReconnect      = 7
+	discordOpInvalidSession = 9
+	discordOpHello          = 10
+)
+
+type discordUser struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+	Bot        bool   `json:"bot"`
+}
+
+type discordRole struct {
+	ID          string `json:"id"`
+	Permissions string `json:"permissions"`
+}
+
+type discordGuild struct {
+	ID      string        `json:"id"`
+	OwnerID string        `json:"owner_id"`
+	Roles   []discordRole `json:"roles"`
+}
+
+type discordOverwrite struct {
+	ID    string `json:"id"`
+	Type  int    `json:"type"`
+	Allow string `json:"allow"`
+	Deny  string `json:"deny"`
+}
+
+type discordChannel struct {
+	GuildID              string             `json:"guild_id"`
+	PermissionOverwrites []discordOverwrite `json:"permission_overwrites"`
+}
+
+type discordMember struct {
+	User  discordUser `json:"user"`
+	Roles []string    `json:"roles"`
+}
+
+// discordPermissionBits reads a permission bitset, which Discord sends as a
+// string
+func discordPermissionBits(s string) uint64 {
+	bits, _ := strconv.ParseUint(s, 10, 64)
+	return bits
+}
+
+// permissions works out what a member can do in a channel of the guild, the
+// way Discord does: their roles' permissions, then the channel's overwrites
+// for @everyone, for their roles and for them
+func (g discordGuild) permissions(ch discordChannel, member discordMember) uint64 {
+	all := ^uint64(0)
+	if member.User.ID == g.OwnerID {
+		return all
+	}
+	var perms uint64
+	for _, role := range g.Roles {
+		// @everyone's role has the guild's id
+		if role.ID == g.ID || slices.Contains(member.Roles, role.ID) {
+			perms |= discordPermissionBits(role.Permissions)
+		}
+	}
+	if perms&discordPermissionAdministrator != 0 {
+		return all
+	}
+
+	// Each level's allows beat its denies, and later levels beat earlier ones
+	var allow, deny [3]uint64
+	for _, o := range ch.PermissionOverwrites {
+		level := -1
+		switch {
+		case o.ID == g.ID:
+			level = 0
+		case o.Type == discordOverwriteMember:
+			if o.ID == member.User.ID {
+				level = 2
+			}
+		case slices.Contains(member.Roles, o.ID):
+			level = 1
+		}
+		if level >= 0 {
+			allow[level] |= discordPermissionBits(o.Allow)
+			deny[level] |= discordPermissionBits(o.Deny)
+		}
+	}
+	for level := range allow {
+		perms = perms&^deny[level] | allow[level]
+	}
+	return perms
+}
+
+type discordMessage struct {
+	ID              string      `json:"id"`
+	ChannelID       string      `json:"channel_id"`
+	GuildID         string      `json:"guild_id"`
+	Author          discordUser `json:"author"`
+	Content         string      `json:"content"`
+	EditedTimestamp string      `json:"edited_timestamp"`
+}
+
+// chatMessage converts m for the platform-neutral pipeline
+func (m discordMessage) chatMessage() ChatMessage {
+	return ChatMessage{
+		team:    m.GuildID,
+		channel: m.ChannelID,
+		user:    m.Author.ID,
+		// Nickname mentions look like <@!123>, which is the same person as <@123>
+		text:     strings.ReplaceAll(m.Content, "<@!", "<@"),
+		ts:       m.ID,
+		postedAt: discordSnowflakeTime(m.ID),
+	}
+}
+
+// discordSnowflakeTime is when the thing with a Discord id was created, or
+// zero if id isn't one
+func discordSnowflakeTime(id string) time.Time {
+	snowflake, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(snowflake>>22) + discordEpoch)
+}
+
+// DiscordConnection is a ChatConnection to Discord's REST API. A message's
+// ts is its id, and Discord replies stand in for threads.
+type DiscordConnection struct {
+	apiURL    string
+	token     string
+	client    *http.Client
+	nameCache sync.Map
+	// botUserID is looked up the first time it's needed
+	botUserID   string
+	botUserIDMu sync.Mutex
+	// members caches each channel's people for membersTTL
+	members    membersCache
+	membersTTL time.Duration
+	// channelGuilds maps the channels in members to their servers, to
+	// forget them when someone joins or leaves the server
+	channelGuilds sync.Map
+	// clock is the real one when nil
+	clock Clock
+}
+
+func NewDiscordConnection(token string, membersTTL time.Duration) *DiscordConnection {
+	return newDiscordConnection(discordAPIURL, token, membersTTL)
+}
+
+func newDiscordConnection(apiURL, token string, membersTTL time.Duration) *DiscordConnection {
+	return &DiscordConnection{apiURL: apiURL, token: token, client: &http.Client{Timeout: 10 * time.Second}, membersTTL: membersTTL}
+}
+
+func (d *DiscordConnection) request(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, d.apiURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+d.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("discord %s %s: %s %s", method, path, resp.Status, msg)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (d *DiscordConnection) NameForUser(userId string) (string, error) {
+	if res, ok := d.nameCache.Load(userId); ok {
+		return res.(string), nil
+	}
+	var user discordUser
+	if err := d.request(http.MethodGet, "/users/"+userId, nil, &user); err != nil {
+		return "", err
+	}
+	name := user.GlobalName
+	if name == "" {
+		name = user.Username
+	}
+	d.nameCache.Store(userId, name)
+	return name, nil
+}
+
+// discordContent cuts msg down to what Discord will accept
+func discordContent(msg string) string {
+	runes := []rune(msg)
+	if len(runes) <= discordMaxMessage {
+		return msg
+	}
+	return string(runes[:discordMaxMessage-1]) + "…"
+}
+
+func (d *DiscordConnection) postMessage(channel, msg, replyTo string) (string, error) {
+	body := map[string]any{"content": discordContent(msg)}
+	if replyTo != "" {
+		body["message_reference"] = map[string]string{"message_id": replyTo}
+	}
+	var posted discordMessage
+	err := d.request(http.MethodPost, "/channels/"+channel+"/messages", body, &posted)
+	return posted.ID, err
+}
+
+func (d *DiscordConnection) PostMessage(channel, msg string) error {
+	_, err := d.postMessage(channel, msg, "")
+	return err
+}
+
+// PostRichMessage posts the text version, since Discord has no blocks
+func (d *DiscordConnection) PostRichMessage(channel string, msg RichMessage) (string, error) {
+	return d.postMessage(channel, msg.text, "")
+}
+
+func (d *DiscordConnection) UpdateRichMessage(channel, ts string, msg RichMessage) error {
+	return d.request(http.MethodPatch, "/channels/"+channel+"/messages/"+ts, map[string]any{"content": discordContent(msg.text)}, nil)
+}
+
+func (d *DiscordConnection) PostThreadReply(channel, threadTs, msg string) error {
+	_, err := d.postMessage(channel, msg, threadTs)
+	return err
+}
+
+// GetUsers lists the people who can see the channel, leaving out bots.
+// They're cached until membersTTL passes or someone joins or leaves the
+// server.
+func (d *DiscordConnection) GetUsers(channel string) ([]string, error) {
+	return d.members.get(channel, d.now, d.membersTTL, d.fetchMembers)
+}
+
+// fetchMembers pages through the server's members for the ones who can see
+// the channel
+func (d *DiscordConnection) fetchMembers(channel string) ([]string, error) {
+	ch, err := d.channel(channel)
+	if err != nil {
+		return nil, err
+	}
+	d.channelGuilds.Store(channel, ch.GuildID)
+	guild, err := d.guild(ch.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]string, 0)
+	after := ""
+	for {
+		query := url.Values{"limit": {fmt.Sprint(discordMaxMembers)}}
+		if after != "" {
+			query.Set("after", after)
+		}
+		var members []discordMember
+		if err := d.request(http.MethodGet, "/guilds/"+guild.ID+"/members?"+query.Encode(), nil, &members); err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if !m.User.Bot && guild.permissions(ch, m)&discordPermissionViewChannel != 0 {
+				users = append(users, m.User.ID)
+			}
+		}
+		if len(members) < discordMaxMembers {
+			return users, nil
+		}
+		after = members[len(members)-1].User.ID
+	}
+}
+
+// forgetGuildMembers drops the cached members of a server's channels, after
+// someone joins or leaves it
+func (d *DiscordConnection) forgetGuildMembers(guildID string) {
+	d.channelGuilds.Range(func(channel, guild any) bool {
+		if guild == guildID {
+			d.members.forget(channel.(string))
+		}
+		return true
+	})
+}
+
+func (d *DiscordConnection) now() time.Time {
+	if d.clock == nil {
+		return NowDefault()
+	}
+	return d.clock.Now()
+}
+
+func (d *DiscordConnection) BotUserID() (string, error) {
+	d.botUserIDMu.Lock()
+	defer d.botUserIDMu.Unlock()
+	if d.botUserID == "" {
+		var me discordUser
+		if err := d.request(http.MethodGet, "/users/@me", nil, &me); err != nil {
+			return "", err
+		}
+		d.botUserID = me.ID
+	}
+	return d.botUserID, nil
+}
+
+func (d *DiscordConnection) channel(channel string) (discordChannel, error) {
+	var ch discordChannel
+	err := d.request(http.MethodGet, "/channels/"+channel, nil, &ch)
+	return ch, err
+}
+
+func (d *DiscordConnection) guild(guildID string) (discordGuild, error) {
+	guild := discordGuild{ID: guildID}
+	err := d.request(http.MethodGet, "/guilds/"+guildID, nil, &guild)
+	return guild, err
+}
+
+// IsAdmin is true for the server's owner, and members with a role that can
+// administer or manage the server
+func (d *DiscordConnection) IsAdmin(channel, userId string) (bool, error) {
+	ch, err := d.channel(channel)
+	if err != nil {
+		return false, err
+	}
+	guild, err := d.guild(ch.GuildID)
+	if err != nil {
+		return false, err
+	}
+	if guild.OwnerID == userId {
+		return true, nil
+	}
+	var member discordMember
+	if err := d.request(http.MethodGet, "/guilds/"+guild.ID+"/members/"+userId, nil, &member); err != nil {
+		return false, err
+	}
+	for _, role := range guild.Roles {
+		if slices.Contains(member.Roles, role.ID) && discordPermissionBits(role.Permissions)&(discordPermissionAdministrator|discordPermissionManageGuild) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DiscordHandler runs the bot on a Discord server, receiving messages over
+// the gateway websocket
+type DiscordHandler struct {
+	Handler
+	bot        *HTTPHandler
+	gatewayURL string
+	token      string
+}
+
+func newDiscordHandler(bot *HTTPHandler, gatewayURL, token string) *DiscordHandler {
+	return &DiscordHandler{bot: bot, gatewayURL: gatewayURL, token: token}
+}
+
+// Init initializes handler
+func (h *DiscordHandler) Init(c *config.BotConfig) {
+	bot := &HTTPHandler{}
+	bot.setup(c, NewDiscordConnection(c.DiscordBotToken, c.MembersCacheTTL))
+	*h = *newDiscordHandler(bot, discordGatewayURL, c.DiscordBotToken)
+}
+
+// Start stays connected to the gateway, only giving up if Discord rejects
+// the bot's token or intents
+func (h *DiscordHandler) Start() error {
+	// Nothing calls /tick when there's no endpoint
+	go h.bot.runTicker(h.bot.config.TickInterval)
+	for {
+		err := h.run(context.Background())
+		if websocket.IsCloseError(err, 4004, 4010, 4011, 4012, 4013, 4014) {
+			return err
+		}
+		log.Printf("Discord gateway disconnected, reconnecting: %v", err)
+		time.Sleep(discordReconnectDelay)
+	}
+}
+
+type discordPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+	S  *int64          `json:"s"`
+	T  string          `json:"t"`
+}
+
+// run connects to the gateway and handles messages until it disconnects
+func (h *DiscordHandler) run(ctx context.Context) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, h.gatewayURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// Unblocks the read below when we're cancelled
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	var hello struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"`
+	}
+	var p discordPayload
+	if err := conn.ReadJSON(&p); err != nil {
+		return err
+	}
+	if p.Op != discordOpHello {
+		return fmt.Errorf("expected hello from the gateway, got op %d", p.Op)
+	}
+	if err := json.Unmarshal(p.D, &hello); err != nil {
+		return err
+	}
+
+	// Gorilla connections don't allow concurrent writes
+	var writeMu sync.Mutex
+	send := func(op int, d any) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(map[string]any{"op": op, "d": d})
+	}
+	var seq atomic.Pointer[int64]
+	heartbeat := func() error {
+		return send(discordOpHeartbeat, seq.Load())
+	}
+
+	err = send(discordOpIdentify, map[string]any{
+		"token":      h.token,
+		"intents":    discordIntentGuilds | discordIntentGuildMembers | discordIntentGuildMessages | discordIntentMessageContent,
+		"properties": map[string]string{"os": "linux", "browser": "wordleturtle", "device": "wordleturtle"},
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(hello.HeartbeatInterval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := heartbeat(); err != nil {
+					log.Printf("Discord heartbeat: %v", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		var p discordPayload
+		if err := conn.ReadJSON(&p); err != nil {
+			return err
+		}
+		if p.S != nil {
+			seq.Store(p.S)
+		}
+		switch p.Op {
+		case discordOpDispatch:
+			if err := h.dispatch(p.T, p.D); err != nil {
+				log.Printf("Discord %s: %v", p.T, err)
+			}
+		case discordOpHeartbeat:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		case discordOpReconnect, discordOpInvalidSession:
+			return fmt.Errorf("gateway asked us to reconnect (op %d)", p.Op)
+		}
+	}
+}
+
+// dispatch queues gateway messages like Slack's events, so they take turns
+// with the ticker and are retried if they fail. The queue is worked off the
+// read loop, which has heartbeats to answer.
+func (h *DiscordHandler) dispatch(event string, data json.RawMessage) error {
+	switch event {
+	case "READY":
+		var ready struct {
+			User discordUser `json:"user"`
+		}
+		if err := json.Unmarshal(data, &ready); err != nil {
+			return err
+		}
+		log.Printf("Connected to Discord as %s", ready.User.Username)
+		return nil
+	case "GUILD_MEMBER_ADD", "GUILD_MEMBER_REMOVE":
+		var member struct {
+			GuildID string `json:"guild_id"`
+		}
+		if err := json.Unmarshal(data, &member); err != nil {
+			return err
+		}
+		if d, ok := h.bot.chat.(*DiscordConnection); ok {
+			d.forgetGuildMembers(member.GuildID)
+		}
+		return nil
+	}
+
+	var m discordMessage
+	var id string
+	switch event {
+	case "MESSAGE_CREATE":
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		if m.Author.Bot {
+			return nil
+		}
+		id = "create:" + m.ID
+	case "MESSAGE_UPDATE":
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		// Updates without an edit are things like link previews appearing
+		if m.Author.Bot || m.EditedTimestamp == "" {
+			return nil
+		}
+		id = "update:" + m.ID + ":" + m.EditedTimestamp
+	case "MESSAGE_DELETE":
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		id = "delete:" + m.ID
+	default:
+		return nil
+	}
+
+	body, err := json.Marshal(discordPayload{T: event, D: data})
+	if err != nil {
+		return err
+	}
+	queued, err := h.bot.db.putEvent(discordEventPrefix+id, body, h.bot.now())
+	if err != nil || !queued {
+		return err
+	}
+	go func() {
+		if err := h.bot.runQueuedEvents(); err != nil {
+			log.Print(err)
+		}
+	}()
+	return nil
+}
+
+// dispatchDiscordEvent does the work for a gateway message that was queued
+// by DiscordHandler
+func (h *HTTPHandler) dispatchDiscordEvent(event string, data json.RawMessage) error {
+	var m discordMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	switch event {
+	case "MESSAGE_CREATE":
+		return h.handleUserMessage(m.chatMessage())
+	case "MESSAGE_UPDATE":
+		return h.handleMessageEdited(m.chatMessage())
+	case "MESSAGE_DELETE":
+		return h.handleMessageRemoved(scope{team: m.GuildID, channel: m.ChannelID}, m.ID)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDiscord stands in for Discord's REST API and gateway. The gateway
+// sends each dispatch after the bot identifies, and the content of every
+// message the bot posts is reported.
+func fakeDiscord(t *testing.T, dispatches []string) (*httptest.Server, chan string) {
+	posted := make(chan string, 10)
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/userid1", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bot discord-test", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"id":"userid1","username":"sean"}`))
+	})
+	mux.HandleFunc("/api/channels/testchannel/messages", func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Content string `json:"content"`
+		}
+		json.NewDecoder(r.Body).Decode(&msg)
+		posted <- msg.Content
+		w.Write([]byte(`{"id":"msg2"}`))
+	})
+	mux.HandleFunc("/gateway", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"op":10,"d":{"heartbeat_interval":45000}}`))
+		var identify struct {
+			Op int `json:"op"`
+			D  struct {
+				Token string `json:"token"`
+			} `json:"d"`
+		}
+		if err := conn.ReadJSON(&identify); err != nil {
+			t.Error(err)
+			return
+		}
+		assert.Equal(t, discordOpIdentify, identify.Op)
+		assert.Equal(t, "discord-test", identify.D.Token)
+		for _, dispatch := range dispatches {
+			conn.WriteMessage(websocket.TextMessage, []byte(dispatch))
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	return httptest.NewServer(mux), posted
+}
+
+func Test_DiscordHandler(t *testing.T) {
+	server, posted := fakeDiscord(t, []string{
+		`{"op":0,"s":1,"t":"READY","d":{"user":{"id":"bot1","username":"WordleTurtle","bot":true}}}`,
+		// Our own posts are ignored
+		`{"op":0,"s":2,"t":"MESSAGE_CREATE","d":{"id":"msg0","channel_id":"testchannel","guild_id":"testteam","author":{"id":"bot1","bot":true},"content":"WordleTurtle help"}}`,
+		`{"op":0,"s":3,"t":"MESSAGE_CREATE","d":{"id":"msg1","channel_id":"testchannel","guild_id":"testteam","author":{"id":"userid1","username":"sean"},"content":"WordleTurtle help"}}`,
+		// Going through the queue, the same message is only handled once
+		`{"op":0,"s":4,"t":"MESSAGE_CREATE","d":{"id":"msg1","channel_id":"testchannel","guild_id":"testteam","author":{"id":"userid1","username":"sean"},"content":"WordleTurtle help"}}`,
+	})
+	defer server.Close()
+
+	chat := newDiscordConnection(server.URL+"/api", "discord-test", time.Minute)
+	gateway := "ws" + strings.TrimPrefix(server.URL, "http") + "/gateway"
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	h := newDiscordHandler(&HTTPHandler{db: db, chat: chat}, gateway, "discord-test")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.run(ctx)
+
+	select {
+	case msg := <-posted:
+		assert.True(t, strings.HasPrefix(msg, "Supported commands are:"), msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("help was never posted")
+	}
+	select {
+	case msg := <-posted:
+		t.Fatalf("unexpected post %q", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	cancel()
+}
+
+func Test_DiscordConnection_GetUsers(t *testing.T) {
+	mux := http.NewServeMux()
+	// Only players can see the channel, except userid4 who's shut out, and
+	// userid5 who's let in
+	mux.HandleFunc("/channels/testchannel", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"testchannel","guild_id":"testteam","permission_overwrites":[
+			{"id":"testteam","type":0,"allow":"0","deny":"1024"},
+			{"id":"players","type":0,"allow":"1024","deny":"0"},
+			{"id":"userid4","type":1,"allow":"0","deny":"1024"},
+			{"id":"userid5","type":1,"allow":"1024","deny":"0"}]}`))
+	})
+	mux.HandleFunc("/guilds/testteam", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"testteam","owner_id":"owner","roles":[
+			{"id":"testteam","permissions":"1024"},
+			{"id":"players","permissions":"0"},
+			{"id":"admins","permissions":"8"}]}`))
+	})
+	var lookups int32
+	mux.HandleFunc("/guilds/testteam/members", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&lookups, 1)
+		assert.Equal(t, "1000", r.URL.Query().Get("limit"))
+		w.Write([]byte(`[{"user":{"id":"userid1"},"roles":["players"]},{"user":{"id":"bot1","bot":true},"roles":["players"]},
+			{"user":{"id":"userid2"},"roles":[]},{"user":{"id":"userid3"},"roles":["admins"]},{"user":{"id":"userid4"},"roles":["players"]},
+			{"user":{"id":"userid5"},"roles":[]},{"user":{"id":"owner"},"roles":[]}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	chat := newDiscordConnection(server.URL, "discord-test", time.Minute)
+	users, err := chat.GetUsers("testchannel")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"userid1", "userid3", "userid5", "owner"}, users)
+
+	// They're cached until someone joins the server
+	_, err = chat.GetUsers("testchannel")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+	h := newDiscordHandler(&HTTPHandler{chat: chat}, "", "discord-test")
+	require.NoError(t, h.dispatch("GUILD_MEMBER_ADD", json.RawMessage(`{"guild_id":"testteam","user":{"id":"userid6"}}`)))
+	_, err = chat.GetUsers("testchannel")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+}
+
+func Test_DiscordConnection_PostThreadReply(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/channels/testchannel/messages", r.URL.Path)
+		var msg struct {
+			Content          string `json:"content"`
+			MessageReference struct {
+				MessageID string `json:"message_id"`
+			} `json:"message_reference"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "Nice one", msg.Content)
+		assert.Equal(t, "msg1", msg.MessageReference.MessageID)
+		w.Write([]byte(`{"id":"msg2"}`))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, newDiscordConnection(server.URL, "discord-test", time.Minute).PostThreadReply("testchannel", "msg1", "Nice one"))
+}
+
+func Test_discordContent(t *testing.T) {
+	assert.Equal(t, "short", discordContent("short"))
+	long := discordContent(strings.Repeat("a", 3000))
+	assert.Equal(t, discordMaxMessage, len([]rune(long)))
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
+
+func Test_discordSnowflakeTime(t *testing.T) {
+	// The example from Discord's docs
+	assert.Equal(t, time.UnixMilli(1462015105796), discordSnowflakeTime("175928847299117063"))
+	assert.True(t, discordSnowflakeTime("nope").IsZero())
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"log"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// handleMessageChanged re-reads an edited message, replacing or removing the
+// result it was posted with
+func (h *HTTPHandler) handleMessageChanged(team string, ev *slackevents.MessageEvent) error {
+	if ev.Message == nil {
+		return nil
+	}
+	// Replies in a thread also "change" the parent message
+	if ev.PreviousMessage != nil && ev.PreviousMessage.Text == ev.Message.Text {
+		return nil
+	}
+
+	sm := ConvertSlackMessage(team, *ev.Message)
+	// The inner message doesn't repeat the channel
+	sm.channel = ev.Channel
+	return h.handleMessageEdited(sm)
+}
+
+// handleMessageEdited replaces or removes the result sm was posted with
+func (h *HTTPHandler) handleMessageEdited(sm ChatMessage) error {
+	if sm.user == "" {
+		// Not from a person, e.g. one of our own summaries being updated
+		return nil
+	}
+	user, err := h.chat.NameForUser(sm.user)
+	if err != nil {
+		return err
+	}
+	if user == "WordleTurtle" {
+		return nil
+	}
+
+	old, err := h.db.getResultByTs(sm.scope(), sm.ts)
+	if err != nil {
+		return err
+	}
+	res := extractMessageResult(sm, user)
+	// Corrections to a result stand, but it can't be moved to another puzzle
+	// that isn't being played
+	if res != nil && (old == nil || res.game != old.game || res.wordlenum != old.wordlenum) {
+		ok, err := h.checkResult(sm, res)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			res = nil
+		}
+	}
+
+	if old != nil && (res == nil || res.game != old.game || res.wordlenum != old.wordlenum) {
+		log.Printf("Removing %s %d for %s after an edit", old.game, old.wordlenum, old.userId)
+		if err := h.db.deleteResult(*old); err != nil {
+			return err
+		}
+		if err := h.refreshLiveSummary(old.scope(), old.game, old.wordlenum); err != nil {
+			return err
+		}
+	}
+	if res == nil {
+		return nil
+	}
+
+	if err := h.db.putResult(*res); err != nil {
+		return err
+	}
+	if err := h.scheduleEndOfDay(*res); err != nil {
+		log.Println(err)
+	}
+	return h.refreshLiveSummary(res.scope(), res.game, res.wordlenum)
+}
+
+// handleMessageDeleted removes the result posted in a deleted message
+func (h *HTTPHandler) handleMessageDeleted(team string, ev *slackevents.MessageEvent) error {
+	if ev.PreviousMessage == nil {
+		return nil
+	}
+	return h.handleMessageRemoved(scope{team: team, channel: ev.Channel}, ev.PreviousMessage.TimeStamp)
+}
+
+// handleMessageRemoved removes the result posted in the message at ts
+func (h *HTTPHandler) handleMessageRemoved(sc scope, ts string) error {
+	old, err := h.db.getResultByTs(sc, ts)
+	if err != nil || old == nil {
+		return err
+	}
+
+	log.Printf("Removing %s %d for %s after a delete", old.game, old.wordlenum, old.userId)
+	if err := h.db.deleteResult(*old); err != nil {
+		return err
+	}
+	return h.refreshLiveSummary(sc, old.game, old.wordlenum)
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack/slackevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_handleMessageChanged(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	today := WordleForDay(NowDefault())
+	old := makeResult("userid1", "sean", today, 5)
+	old.team, old.channel, old.ts = "testteam", "testchannel", "1700000000.000100"
+	ev := &slackevents.MessageEvent{
+		SubType:         "message_changed",
+		Channel:         "testchannel",
+		Message:         &slackevents.MessageEvent{User: "userid1", Text: fmt.Sprintf("Wordle %d 3/6", today), TimeStamp: old.ts},
+		PreviousMessage: &slackevents.MessageEvent{User: "userid1", Text: fmt.Sprintf("Wordle %d 5/6", today), TimeStamp: old.ts},
+	}
+
+	fixed := old
+	fixed.score = 3
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1", "userid2"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+	mockDb.On("getResultByTs", testScope, old.ts).Return(&old, nil)
+	mockDb.On("putResult", fixed).Return(nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("putJob", mock.Anything).Return(nil)
+	mockDb.On("getSummaryTs", testScope, "Wordle", today).Return("1700000000.000200", nil)
+	mockDb.On("getDailyResults", testScope, "Wordle", today).Return([]Result{fixed}, nil)
+	summaryMatcher := mock.MatchedBy(func(msg RichMessage) bool {
+		return strings.Contains(msg.text, "3/6: sean")
+	})
+	mockSlack.On("UpdateRichMessage", "testchannel", "1700000000.000200", summaryMatcher).Return(nil)
+
+	assert.Nil(t, h.handleMessageChanged("testteam", ev))
+	mockDb.AssertExpectations(t)
+	mockSlack.AssertExpectations(t)
+	mockDb.AssertNotCalled(t, "deleteResult", mock.Anything)
+}
+
+func Test_handleMessageChanged_NoLongerAResult(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	old := makeResult("userid1", "sean", 917, 5)
+	old.team, old.channel, old.ts = "testteam", "testchannel", "1700000000.000100"
+	ev := &slackevents.MessageEvent{
+		SubType:         "message_changed",
+		Channel:         "testchannel",
+		Message:         &slackevents.MessageEvent{User: "userid1", Text: "oops, wrong channel", TimeStamp: old.ts},
+		PreviousMessage: &slackevents.MessageEvent{User: "userid1", Text: "Wordle 917 5/6", TimeStamp: old.ts},
+	}
+
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockDb.On("getResultByTs", testScope, old.ts).Return(&old, nil)
+	mockDb.On("deleteResult", old).Return(nil)
+	// There's no summary for this puzzle to refresh
+	mockDb.On("getSummaryTs", testScope, "Wordle", 917).Return("", nil)
+
+	assert.Nil(t, h.handleMessageChanged("testteam", ev))
+	mockDb.AssertExpectations(t)
+}
+
+func Test_handleMessageChanged_ThreadReply(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	// Replying in a thread changes the parent, but not its text
+	ev := &slackevents.MessageEvent{
+		SubType:         "message_changed",
+		Channel:         "testchannel",
+		Message:         &slackevents.MessageEvent{User: "userid1", Text: "Wordle 917 5/6", TimeStamp: "1700000000.000100"},
+		PreviousMessage: &slackevents.MessageEvent{User: "userid1", Text: "Wordle 917 5/6", TimeStamp: "1700000000.000100"},
+	}
+
+	assert.Nil(t, h.handleMessageChanged("testteam", ev))
+	mockDb.AssertNotCalled(t, "getResultByTs", mock.Anything, mock.Anything)
+}
+
+func Test_handleMessageDeleted(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	old := makeResult("userid1", "sean", 917, 5)
+	old.team, old.channel, old.ts = "testteam", "testchannel", "1700000000.000100"
+	ev := &slackevents.MessageEvent{
+		SubType:         "message_deleted",
+		Channel:         "testchannel",
+		PreviousMessage: &slackevents.MessageEvent{User: "userid1", Text: "Wordle 917 5/6", TimeStamp: old.ts},
+	}
+
+	mockDb.On("getResultByTs", testScope, old.ts).Return(&old, nil)
+	mockDb.On("deleteResult", old).Return(nil)
+	mockDb.On("getSummaryTs", testScope, "Wordle", 917).Return("1700000000.000200", nil)
+	mockDb.On("getDailyResults", testScope, "Wordle", 917).Return([]Result{}, nil)
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("UpdateRichMessage", "testchannel", "1700000000.000200", mock.Anything).Return(nil)
+
+	assert.Nil(t, h.handleMessageDeleted("testteam", ev))
+	mockDb.AssertExpectations(t)
+	mockSlack.AssertExpectations(t)
+
+	// Deleting other messages does nothing
+	mockDb.On("getResultByTs", testScope, "1700000000.000900").Return((*Result)(nil), nil)
+	ev.PreviousMessage.TimeStamp = "1700000000.000900"
+	assert.Nil(t, h.handleMessageDeleted("testteam", ev))
+	mockDb.AssertNumberOfCalls(t, "deleteResult", 1)
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	// eventRetention is how long we remember event ids. Slack gives up
+	// retrying well within this.
+	eventRetention = 24 * time.Hour
+	// workKickTimeout bounds how long handle waits on /work. The other
+	// invocation carries on once we hang up.
+	workKickTimeout = time.Second
+	// eventLease is how long a worker has to process an event it claimed
+	// before another may assume it died and try again
+	eventLease = 5 * time.Minute
+	// maxEventAttempts is how many times an event is tried before we give up
+	// on it
+	maxEventAttempts = 5
+)
+
+// QueuedEvent is the raw body of a Slack event waiting to be processed. Ids
+// starting with slashEventPrefix are slash commands, and ones starting with
+// discordEventPrefix are Discord gateway messages.
+type QueuedEvent struct {
+	id         string
+	body       []byte
+	receivedAt time.Time
+	// attempts is how many times it's been claimed before
+	attempts int
+}
+
+// kickWorker gets the queue processed without holding up the response to
+// Slack. If it never happens the next tick picks the events up.
+func (h *HTTPHandler) kickWorker() {
+	if h.config.WorkURL == "" {
+		go func() {
+			if err := h.runQueuedEvents(); err != nil {
+				log.Print(err)
+			}
+		}()
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.config.WorkURL, nil)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+h.config.TickSecret)
+	client := http.Client{Timeout: workKickTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Most likely our timeout, which is fine
+		log.Printf("Kicking %s: %v", h.config.WorkURL, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// handleWork processes queued events. handle calls it on Lambda so the work
+// happens in an invocation that isn't frozen after replying to Slack.
+func (h *HTTPHandler) handleWork(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizedTick(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err := h.runQueuedEvents(); err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// runQueuedEvents claims and processes each queued event. An event is only
+// done once it's processed successfully. One that fails is released for the
+// next run to retry, up to maxEventAttempts, and one whose worker died is
+// retried once its claim lapses.
+func (h *HTTPHandler) runQueuedEvents() error {
+	h.working.Lock()
+	defer h.working.Unlock()
+
+	now := h.now()
+	events, err := h.db.getQueuedEvents(now)
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		claimed, err := h.db.claimEvent(ev.id, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if err := h.runEvent(ev); err != nil {
+			return err
+		}
+	}
+	return h.db.deleteEventsBefore(now.Add(-eventRetention))
+}
+
+// runEvent processes a claimed event, then finishes or releases it
+func (h *HTTPHandler) runEvent(ev QueuedEvent) error {
+	var process func() error
+	switch {
+	case strings.HasPrefix(ev.id, slashEventPrefix):
+		var cmd slack.SlashCommand
+		if err := json.Unmarshal(ev.body, &cmd); err != nil {
+			log.Printf("Event %s: %v", ev.id, err)
+			return h.db.finishEvent(ev.id)
+		}
+		process = func() error { return h.respondToSlashCommand(cmd) }
+	case strings.HasPrefix(ev.id, discordEventPrefix):
+		var p discordPayload
+		if err := json.Unmarshal(ev.body, &p); err != nil {
+			log.Printf("Event %s: %v", ev.id, err)
+			return h.db.finishEvent(ev.id)
+		}
+		process = func() error { return h.dispatchDiscordEvent(p.T, p.D) }
+	default:
+		eventsAPIEvent, err := slackevents.ParseEvent(json.RawMessage(ev.body), slackevents.OptionNoVerifyToken())
+		if err != nil {
+			// It'll never parse, so don't retry it
+			log.Printf("Event %s: %v", ev.id, err)
+			return h.db.finishEvent(ev.id)
+		}
+		process = func() error { return h.dispatchEvent(eventsAPIEvent) }
+	}
+	if err := recovered(process); err != nil {
+		if ev.attempts+1 >= maxEventAttempts {
+			log.Printf("Giving up on event %s after %d attempts: %v", ev.id, ev.attempts+1, err)
+			return h.db.finishEvent(ev.id)
+		}
+		log.Printf("Event %s failed, will retry: %v", ev.id, err)
+		return h.db.releaseEvent(ev.id)
+	}
+	return h.db.finishEvent(ev.id)
+}
+
+// recovered runs process, turning a panic into an error so the event is
+// retried and given up on like any other failure
+func recovered(process func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return process()
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wordleturtle/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testEventBody = `{"token":"x","team_id":"testteam","type":"event_callback","event_id":"Ev1","event":{"type":"message","channel":"testchannel","user":"userid1","text":"WordleTurtle help","ts":"1700000000.000100"}}`
+
+// signedRequest builds an events request signed the way Slack does
+func signedRequest(secret, body string) *http.Request {
+	ts := fmt.Sprint(time.Now().Unix())
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func Test_handle_QueuesOnce(t *testing.T) {
+	var kicks int32
+	work := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer ticksecret", r.Header.Get("Authorization"))
+		atomic.AddInt32(&kicks, 1)
+	}))
+	defer work.Close()
+
+	mockDb := new(MockDB)
+	h := &HTTPHandler{
+		config: &config.BotConfig{SigningSecret: "signingsecret", TickSecret: "ticksecret", WorkURL: work.URL},
+		db:     mockDb,
+	}
+	mockDb.On("putEvent", "Ev1", []byte(testEventBody), mock.Anything).Return(true, nil).Once()
+	mockDb.On("putEvent", "Ev1", []byte(testEventBody), mock.Anything).Return(false, nil).Once()
+
+	w := httptest.NewRecorder()
+	h.handle(w, signedRequest("signingsecret", testEventBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Slack retries, and we ack without doing anything
+	req := signedRequest("signingsecret", testEventBody)
+	req.Header.Set("X-Slack-Retry-Num", "1")
+	w = httptest.NewRecorder()
+	h.handle(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&kicks))
+	mockDb.AssertExpectations(t)
+}
+
+func Test_handle_BadSignature(t *testing.T) {
+	h := &HTTPHandler{config: &config.BotConfig{SigningSecret: "signingsecret"}}
+
+	w := httptest.NewRecorder()
+	h.handle(w, signedRequest("wrong", testEventBody))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func Test_runQueuedEvents(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	mockDb.On("getQueuedEvents", mock.Anything).Return([]QueuedEvent{
+		{id: "Ev1", body: []byte(testEventBody)},
+		{id: "Ev2", body: []byte(testEventBody)},
+	}, nil)
+	mockDb.On("claimEvent", "Ev1", mock.Anything).Return(true, nil)
+	// Another worker got to this one
+	mockDb.On("claimEvent", "Ev2", mock.Anything).Return(false, nil)
+	mockDb.On("finishEvent", "Ev1").Return(nil).Once()
+	mockDb.On("deleteEventsBefore", mock.Anything).Return(nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	helpMatcher := mock.MatchedBy(func(msg string) bool {
+		return strings.HasPrefix(msg, "Supported commands are:")
+	})
+	mockSlack.On("PostMessage", "testchannel", helpMatcher).Return(nil).Once()
+
+	assert.Nil(t, h.runQueuedEvents())
+	mockSlack.AssertExpectations(t)
+	mockDb.AssertExpectations(t)
+}
+
+func Test_runQueuedEvents_JudgedWhenPosted(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	var out strings.Builder
+	// Picked up well after the grace period for yesterday's puzzle
+	h := &HTTPHandler{db: db, chat: &replConnection{out: &out, users: []string{"userid1"}}, clock: newFakeClock(DayForWordle(1284).Add(5 * time.Hour))}
+
+	posted := DayForWordle(1283).Add(23*time.Hour + 59*time.Minute)
+	body := fmt.Sprintf(`{"token":"x","team_id":"testteam","type":"event_callback","event_id":"Ev1","event":{"type":"message","channel":"testchannel","user":"userid1","text":"Wordle 1,283 3/6","ts":"%d.000100"}}`, posted.Unix())
+	_, err = db.putEvent("Ev1", []byte(body), posted)
+	require.NoError(t, err)
+	require.NoError(t, h.runQueuedEvents())
+
+	dailies, err := db.getDailyResults(testScope, "Wordle", 1283)
+	require.NoError(t, err)
+	assert.Len(t, dailies, 1)
+	assert.NotContains(t, out.String(), "too long ago")
+}
+
+func Test_runQueuedEvents_Retries(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	clock := newFakeClock(time.Date(2024, 12, 23, 9, 0, 0, 0, DefaultLocation()))
+	h := &HTTPHandler{db: db, chat: mockSlack, clock: clock}
+
+	queued, err := db.putEvent("Ev1", []byte(testEventBody), clock.Now())
+	require.NoError(t, err)
+	require.True(t, queued)
+
+	// Slack is down, so the event stays queued
+	mockSlack.On("NameForUser", "userid1").Return("", errors.New("slack is down")).Once()
+	require.NoError(t, h.runQueuedEvents())
+	events, err := db.getQueuedEvents(clock.Now())
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].attempts)
+
+	// And the next run gets it done
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("PostMessage", "testchannel", mock.Anything).Return(nil).Once()
+	clock.Advance(time.Minute)
+	require.NoError(t, h.runQueuedEvents())
+	events, err = db.getQueuedEvents(clock.Now())
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	mockSlack.AssertExpectations(t)
+
+	// An event that keeps failing is given up on
+	queued, err = db.putEvent("Ev2", []byte(strings.Replace(testEventBody, "Ev1", "Ev2", 1)), clock.Now())
+	require.NoError(t, err)
+	require.True(t, queued)
+	mockSlack.On("PostMessage", "testchannel", mock.Anything).Return(errors.New("slack is down"))
+	for i := 0; i < maxEventAttempts; i++ {
+		require.NoError(t, h.runQueuedEvents())
+	}
+	events, err = db.getQueuedEvents(clock.Now())
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func Test_runQueuedEvents_Panics(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	clock := newFakeClock(time.Date(2024, 12, 23, 9, 0, 0, 0, DefaultLocation()))
+	h := &HTTPHandler{db: db, chat: mockSlack, clock: clock}
+
+	queued, err := db.putEvent("Ev1", []byte(testEventBody), clock.Now())
+	require.NoError(t, err)
+	require.True(t, queued)
+
+	// A panic is retried like an error, then given up on
+	mockSlack.On("NameForUser", "userid1").Panic("boom")
+	require.NoError(t, h.runQueuedEvents())
+	events, err := db.getQueuedEvents(clock.Now())
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].attempts)
+
+	for i := 1; i < maxEventAttempts; i++ {
+		require.NoError(t, h.runQueuedEvents())
+	}
+	events, err = db.getQueuedEvents(clock.Now())
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func Test_handleWork_Unauthorized(t *testing.T) {
+	h := &HTTPHandler{config: &config.BotConfig{TickSecret: "secret"}}
+
+	req := httptest.NewRequest(http.MethodPost, "/work", nil)
+	w := httptest.NewRecorder()
+	h.handleWork(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
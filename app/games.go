@@ -0,0 +1,194 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Game knows how to recognise and score one daily puzzle.
+//
+// Scores are stored as-is in the results table and are always "lower is
+// better", but what they mean (guesses, mistakes, seconds...) is up to the
+// game. Points puts every game on the same 1-7 scale Wordle uses, with 7
+// being the best possible result.
+type Game interface {
+	// Name is the display name and the key results are stored under
+	Name() string
+	// Parse returns the result shared in the message, or nil if the
+	// message isn't a share for this game
+	Parse(message string) *Result
+	// PuzzleTitle names a puzzle in posts, e.g. "Wordle #1283"
+	PuzzleTitle(num int) string
+	// ScoreLabel formats a score for summaries, e.g. "3/6"
+	ScoreLabel(score int) string
+	// Points converts a score into leaderboard points
+	Points(score int) int
+	// Solved reports whether a score finished the puzzle (i.e. isn't an X)
+	Solved(score int) bool
+	// ValidScore reports whether the game can give a score, to check shares
+	// and the ones admins type in
+	ValidScore(score int) bool
+	// Buckets names the columns of the leaderboard distribution
+	Buckets() []string
+	// Bucket returns the index into Buckets for a score
+	Bucket(score int) int
+	// DayForPuzzle returns the day a puzzle number was released
+	DayForPuzzle(num int) time.Time
+	// PuzzleForDay returns the puzzle number released on a day
+	PuzzleForDay(day time.Time) int
+}
+
+// games holds the registered games in the order their parsers are tried
+var games []Game
+
+// registerGame adds a game, whose parser is tried after those of the games
+// registered before it. Names must be unique, as results are stored under
+// them.
+func registerGame(g Game) {
+	if gameByName(g.Name()) != nil {
+		panic(fmt.Sprintf("%s is already registered", g.Name()))
+	}
+	games = append(games, g)
+}
+
+func gameByName(name string) Game {
+	for _, g := range games {
+		if strings.EqualFold(g.Name(), name) {
+			return g
+		}
+	}
+	return nil
+}
+
+// gameFor returns the game a result was recorded for. Results from before
+// games were tracked are Wordles.
+func gameFor(r Result) Game {
+	if g := gameByName(r.game); g != nil {
+		return g
+	}
+	return wordle{}
+}
+
+// extractResult runs the message past each registered game's parser. A share
+// with a score the game can't give is turned away.
+func extractResult(message string) *Result {
+	for _, g := range games {
+		res := g.Parse(message)
+		if res == nil {
+			continue
+		}
+		if !g.ValidScore(res.score) {
+			log.Printf("Rejecting %s: score %d", g.PuzzleTitle(res.wordlenum), res.score)
+			return nil
+		}
+		res.game = g.Name()
+		return res
+	}
+	return nil
+}
+
+// bucketFor returns the index into game's Buckets for a score, or false for
+// scores that fit none, which were stored before shares were checked
+func bucketFor(game Game, score int) (int, bool) {
+	bucket := game.Bucket(score)
+	return bucket, bucket >= 0 && bucket < len(game.Buckets())
+}
+
+// affirmationScore maps a result onto the Wordle score with the same points,
+// so the affirmations (which are written for Wordle) work for every game
+func affirmationScore(r Result) int {
+	return 8 - gameFor(r).Points(r.score)
+}
+
+// dailyEpoch maps puzzle numbers to days for games that release one puzzle
+// per day, counting from a known puzzle
+type dailyEpoch struct {
+	num               int
+	year, month, date int
+}
+
+func (e dailyEpoch) DayForPuzzle(num int) time.Time {
+	// This might get out of sync at some point if a day ever gets skipped
+	sentinel := time.Date(e.year, time.Month(e.month), e.date, 0, 0, 0, 0, DefaultLocation())
+	return sentinel.AddDate(0, 0, num-e.num)
+}
+
+func (e dailyEpoch) PuzzleForDay(day time.Time) int {
+	sentinel := time.Date(e.year, time.Month(e.month), e.date, 0, 0, 0, 0, time.UTC)
+	// Count calendar days in UTC so daylight savings can't lose an hour
+	day = day.In(DefaultLocation())
+	today := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	elapsedDays := today.Sub(sentinel).Hours() / 24
+	return e.num + int(elapsedDays)
+}
+
+// shortcodes are the names Slack rewrites emoji to in message text
+var shortcodes = map[string]string{
+	":large_green_square:":  "🟩",
+	":large_orange_square:": "🟧",
+	":large_yellow_square:": "🟨",
+	":large_blue_square:":   "🟦",
+	":large_purple_square:": "🟪",
+	":large_red_square:":    "🟥",
+	":white_large_square:":  "⬜",
+	":black_large_square:":  "⬛",
+	":large_blue_circle:":   "🔵",
+	":large_yellow_circle:": "🟡",
+	":bulb:":                "💡",
+	":one:":                 "1️⃣",
+	":two:":                 "2️⃣",
+	":three:":               "3️⃣",
+	":four:":                "4️⃣",
+	":five:":                "5️⃣",
+	":six:":                 "6️⃣",
+	":seven:":               "7️⃣",
+	":eight:":               "8️⃣",
+	":nine:":                "9️⃣",
+}
+
+// normalizeEmoji turns Slack shortcodes back into the emoji that was pasted,
+// and drops variation selectors and keycaps so each symbol is a single rune
+func normalizeEmoji(message string) string {
+	for code, emoji := range shortcodes {
+		message = strings.ReplaceAll(message, code, emoji)
+	}
+	message = strings.ReplaceAll(message, "\ufe0f", "")
+	return strings.ReplaceAll(message, "\u20e3", "")
+}
+
+// parseEmojiRow converts a line of emoji into a pattern using the symbols
+// map. Returns false if the line contains anything else.
+func parseEmojiRow(line string, symbols map[rune]rune) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", false
+	}
+	row := ""
+	for _, r := range line {
+		s, ok := symbols[r]
+		if !ok {
+			return "", false
+		}
+		row += string(s)
+	}
+	return row, true
+}
+
+// extractEmojiRows pulls the block of emoji rows out of a share. Everything
+// before the first row (the header) and after the last row is ignored.
+func extractEmojiRows(message string, symbols map[rune]rune) []string {
+	var rows []string
+	for _, line := range strings.Split(normalizeEmoji(message), "\n") {
+		row, ok := parseEmojiRow(line, symbols)
+		if ok {
+			rows = append(rows, row)
+			continue
+		}
+		if len(rows) > 0 && strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+	return rows
+}
@@ -0,0 +1,400 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerGame(wordle{})
+	registerGame(connections{})
+	registerGame(strands{})
+	registerGame(mini{})
+	registerGame(quordle{})
+	registerGame(nerdle{})
+}
+
+// parsePuzzleNum reads a puzzle number, which may use a thousands separator
+func parsePuzzleNum(s string) int {
+	num, _ := strconv.Atoi(strings.Replace(s, ",", "", -1))
+	return num
+}
+
+// pluralize formats a count with a singular or plural noun
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// =====
+// Wordle
+// =====
+
+type wordle struct{}
+
+func (wordle) Name() string { return "Wordle" }
+
+func (wordle) Parse(message string) *Result { return extractWordleResult(message) }
+
+func (wordle) PuzzleTitle(num int) string { return fmt.Sprintf("Wordle #%d", num) }
+
+func (wordle) ScoreLabel(score int) string {
+	if score > 6 {
+		return "x/6"
+	}
+	return fmt.Sprintf("%d/6", score)
+}
+
+func (wordle) Points(score int) int { return 8 - score }
+
+func (wordle) Solved(score int) bool { return score <= 6 }
+
+// An X is stored as 7
+func (wordle) ValidScore(score int) bool { return score >= 1 && score <= 7 }
+
+func (wordle) Buckets() []string {
+	return []string{"1s", "2s", "3s", "4s", "5s", "6s", "Xs"}
+}
+
+func (wordle) Bucket(score int) int { return score - 1 }
+
+func (wordle) DayForPuzzle(num int) time.Time { return DayForWordle(num) }
+
+func (wordle) PuzzleForDay(day time.Time) int { return WordleForDay(day) }
+
+// =====
+// Nerdle - scored exactly like Wordle
+// =====
+
+type nerdle struct {
+	wordle
+}
+
+var nerdleEpoch = dailyEpoch{num: 1, year: 2022, month: 1, date: 20}
+
+func (nerdle) Name() string { return "Nerdle" }
+
+func (nerdle) Parse(message string) *Result {
+	matcher := regexp.MustCompile(`^\s*nerdlegame ([\d,]+) (\d|x|X)/6`)
+	matches := matcher.FindStringSubmatch(message)
+	if len(matches) == 0 {
+		return nil
+	}
+	r := Result{wordlenum: parsePuzzleNum(matches[1]), score: 7}
+	if matches[2] != "x" && matches[2] != "X" {
+		r.score, _ = strconv.Atoi(matches[2])
+	}
+	return &r
+}
+
+func (nerdle) PuzzleTitle(num int) string { return fmt.Sprintf("Nerdle #%d", num) }
+
+func (nerdle) DayForPuzzle(num int) time.Time { return nerdleEpoch.DayForPuzzle(num) }
+
+func (nerdle) PuzzleForDay(day time.Time) int { return nerdleEpoch.PuzzleForDay(day) }
+
+// =====
+// Connections - score is the number of mistakes, 4 means failed
+// =====
+
+type connections struct{}
+
+var connectionsEpoch = dailyEpoch{num: 1, year: 2023, month: 6, date: 12}
+
+const connectionsFailed = 4
+
+var connectionsColors = map[rune]rune{
+	'🟨': 'Y',
+	'🟩': 'G',
+	'🟦': 'B',
+	'🟪': 'P',
+}
+
+func (connections) Name() string { return "Connections" }
+
+func (connections) Parse(message string) *Result {
+	matcher := regexp.MustCompile(`^\s*Connections\s*\n\s*Puzzle #([\d,]+)`)
+	matches := matcher.FindStringSubmatch(message)
+	if len(matches) == 0 {
+		return nil
+	}
+	r := Result{wordlenum: parsePuzzleNum(matches[1])}
+	r.grid = extractEmojiRows(message, connectionsColors)
+
+	solved := 0
+	for _, row := range r.grid {
+		if len(row) != 4 {
+			return nil
+		}
+		if strings.Count(row, row[:1]) == 4 {
+			solved++
+		} else {
+			r.score++
+		}
+	}
+	if solved != 4 && r.score != connectionsFailed {
+		return nil
+	}
+	return &r
+}
+
+func (connections) PuzzleTitle(num int) string { return fmt.Sprintf("Connections #%d", num) }
+
+func (connections) DayForPuzzle(num int) time.Time { return connectionsEpoch.DayForPuzzle(num) }
+
+func (connections) PuzzleForDay(day time.Time) int { return connectionsEpoch.PuzzleForDay(day) }
+
+func (connections) ScoreLabel(score int) string {
+	switch score {
+	case 0:
+		return "perfect"
+	case connectionsFailed:
+		return "failed"
+	}
+	return pluralize(score, "mistake")
+}
+
+func (connections) Points(score int) int {
+	if score >= connectionsFailed {
+		return 1
+	}
+	return 6 - score
+}
+
+func (connections) Solved(score int) bool { return score < connectionsFailed }
+
+func (connections) ValidScore(score int) bool { return score >= 0 && score <= connectionsFailed }
+
+func (connections) Buckets() []string {
+	return []string{"Perfect", "1 miss", "2 miss", "3 miss", "Failed"}
+}
+
+func (connections) Bucket(score int) int { return score }
+
+// =====
+// Strands - score is the number of hints used
+// =====
+
+type strands struct{}
+
+var strandsEpoch = dailyEpoch{num: 1, year: 2024, month: 3, date: 4}
+
+var strandsSymbols = map[rune]rune{
+	'💡': 'H',
+	'🔵': 'T',
+	'🟡': 'S',
+}
+
+func (strands) Name() string { return "Strands" }
+
+func (strands) Parse(message string) *Result {
+	matcher := regexp.MustCompile(`^\s*Strands #([\d,]+)`)
+	matches := matcher.FindStringSubmatch(message)
+	if len(matches) == 0 {
+		return nil
+	}
+	r := Result{wordlenum: parsePuzzleNum(matches[1])}
+	r.grid = extractEmojiRows(message, strandsSymbols)
+	if len(r.grid) == 0 {
+		return nil
+	}
+	for _, row := range r.grid {
+		r.score += strings.Count(row, "H")
+	}
+	return &r
+}
+
+func (strands) PuzzleTitle(num int) string { return fmt.Sprintf("Strands #%d", num) }
+
+func (strands) DayForPuzzle(num int) time.Time { return strandsEpoch.DayForPuzzle(num) }
+
+func (strands) PuzzleForDay(day time.Time) int { return strandsEpoch.PuzzleForDay(day) }
+
+func (strands) ScoreLabel(score int) string {
+	if score == 0 {
+		return "no hints"
+	}
+	return pluralize(score, "hint")
+}
+
+func (s strands) Points(score int) int { return 6 - s.Bucket(score) }
+
+// You can't fail Strands, only use more hints
+func (strands) Solved(score int) bool { return true }
+
+func (strands) ValidScore(score int) bool { return score >= 0 }
+
+func (strands) Buckets() []string {
+	return []string{"0 hints", "1 hint", "2 hints", "3+ hints"}
+}
+
+func (strands) Bucket(score int) int {
+	if score > 3 {
+		return 3
+	}
+	return score
+}
+
+// =====
+// NYT Mini - score is the solve time in seconds. The Mini doesn't have
+// puzzle numbers so it borrows Wordle's numbering for days.
+// =====
+
+type mini struct{}
+
+// miniBuckets are the upper bounds (in seconds) of each leaderboard column
+var miniBuckets = []int{30, 60, 120, 300}
+
+func (mini) Name() string { return "Mini" }
+
+func (mini) Parse(message string) *Result {
+	matcher := regexp.MustCompile(`I solved the (?:\w+ )?(\d{1,2})/(\d{1,2})/(\d{4}) New York Times Mini Crossword in (\d+):(\d{2})`)
+	matches := matcher.FindStringSubmatch(message)
+	if len(matches) == 0 {
+		return nil
+	}
+	month, _ := strconv.Atoi(matches[1])
+	day, _ := strconv.Atoi(matches[2])
+	year, _ := strconv.Atoi(matches[3])
+	minutes, _ := strconv.Atoi(matches[4])
+	seconds, _ := strconv.Atoi(matches[5])
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, DefaultLocation())
+	return &Result{
+		wordlenum: WordleForDay(date),
+		score:     minutes*60 + seconds,
+	}
+}
+
+func (mini) PuzzleTitle(num int) string {
+	return "Mini for " + DayForWordle(num).Format("Mon Jan 2")
+}
+
+func (mini) ScoreLabel(score int) string {
+	return fmt.Sprintf("%d:%02d", score/60, score%60)
+}
+
+func (m mini) Points(score int) int { return 6 - m.Bucket(score) }
+
+func (mini) Solved(score int) bool { return true }
+
+func (mini) ValidScore(score int) bool { return score > 0 }
+
+func (mini) Buckets() []string {
+	return []string{"<30s", "<1m", "<2m", "<5m", "5m+"}
+}
+
+func (mini) Bucket(score int) int {
+	for i, limit := range miniBuckets {
+		if score < limit {
+			return i
+		}
+	}
+	return len(miniBuckets)
+}
+
+func (mini) DayForPuzzle(num int) time.Time { return DayForWordle(num) }
+
+func (mini) PuzzleForDay(day time.Time) int { return WordleForDay(day) }
+
+// =====
+// Quordle - score is the total guesses across the four boards. A failed
+// board counts as quordleMissed, which puts any failure above the worst
+// possible solve.
+// =====
+
+type quordle struct{}
+
+var quordleEpoch = dailyEpoch{num: 1, year: 2022, month: 1, date: 24}
+
+const (
+	// quordleMaxSolved is the highest score without a failed board
+	quordleMaxSolved = 4 * 9
+	quordleMissed    = quordleMaxSolved + 1
+)
+
+// quordleBuckets are the upper bounds of each leaderboard column
+var quordleBuckets = []int{20, 24, 28, quordleMaxSolved + 1}
+
+var quordleSymbols = map[rune]rune{
+	'1': '1', '2': '2', '3': '3', '4': '4', '5': '5',
+	'6': '6', '7': '7', '8': '8', '9': '9',
+	'🟥': 'X',
+}
+
+func (quordle) Name() string { return "Quordle" }
+
+func (quordle) Parse(message string) *Result {
+	matcher := regexp.MustCompile(`^\s*Daily Quordle ([\d,]+)`)
+	matches := matcher.FindStringSubmatch(message)
+	if len(matches) == 0 {
+		return nil
+	}
+	r := Result{wordlenum: parsePuzzleNum(matches[1])}
+	r.grid = extractEmojiRows(message, quordleSymbols)
+	if len(r.grid) != 2 || len(r.grid[0]) != 2 || len(r.grid[1]) != 2 {
+		return nil
+	}
+	for _, row := range r.grid {
+		for _, board := range row {
+			if board == 'X' {
+				r.score += quordleMissed
+			} else {
+				r.score += int(board - '0')
+			}
+		}
+	}
+	return &r
+}
+
+func (quordle) PuzzleTitle(num int) string { return fmt.Sprintf("Quordle #%d", num) }
+
+func (quordle) DayForPuzzle(num int) time.Time { return quordleEpoch.DayForPuzzle(num) }
+
+func (quordle) PuzzleForDay(day time.Time) int { return quordleEpoch.PuzzleForDay(day) }
+
+func (q quordle) ScoreLabel(score int) string {
+	if !q.Solved(score) {
+		return "failed"
+	}
+	return fmt.Sprintf("%d total", score)
+}
+
+func (q quordle) Points(score int) int {
+	if !q.Solved(score) {
+		return 1
+	}
+	return 6 - q.Bucket(score)
+}
+
+func (quordle) Solved(score int) bool { return score <= quordleMaxSolved }
+
+// ValidScore checks the score can be split into failed boards and solved
+// ones that took 1 to 9 guesses each
+func (quordle) ValidScore(score int) bool {
+	for missed := 0; missed <= 4; missed++ {
+		guesses := score - missed*quordleMissed
+		if guesses >= 4-missed && guesses <= 9*(4-missed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (quordle) Buckets() []string {
+	return []string{"<20", "20-23", "24-27", "28+", "Failed"}
+}
+
+func (quordle) Bucket(score int) int {
+	for i, limit := range quordleBuckets {
+		if score < limit {
+			return i
+		}
+	}
+	return len(quordleBuckets)
+}
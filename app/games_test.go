@@ -0,0 +1,91 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractResult(t *testing.T) {
+	inputs := []struct {
+		message   string
+		game      string
+		wordlenum int
+		score     int
+	}{
+		{message: "Wordle 1,283 4/6*", game: "Wordle", wordlenum: 1283, score: 4},
+		{message: "Connections\nPuzzle #512\n🟨🟨🟨🟨\n🟩🟦🟩🟩\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪", game: "Connections", wordlenum: 512, score: 1},
+		{message: "Connections\nPuzzle #512\n🟨🟨🟨🟩\n🟩🟦🟩🟩\n🟪🟦🟦🟦\n🟦🟪🟪🟪", game: "Connections", wordlenum: 512, score: 4},
+		{message: "Strands #219\n“Mind your manners”\n💡🔵🔵🟡\n💡🔵🔵", game: "Strands", wordlenum: 219, score: 2},
+		{message: "I solved the Monday 10/14/2024 New York Times Mini Crossword in 1:05!", game: "Mini", wordlenum: 1213, score: 65},
+		{message: "Daily Quordle 1024\n6️⃣5️⃣\n8️⃣🟥\nm-w.com/games/quordle/", game: "Quordle", wordlenum: 1024, score: 19 + quordleMissed},
+		{message: "Daily Quordle 1024\n:four::five:\n:six::seven:", game: "Quordle", wordlenum: 1024, score: 22},
+		{message: "nerdlegame 728 3/6", game: "Nerdle", wordlenum: 728, score: 3},
+	}
+
+	for _, testcase := range inputs {
+		res := extractResult(testcase.message)
+		if assert.NotNil(t, res, testcase.message) {
+			assert.Equal(t, testcase.game, res.game)
+			assert.Equal(t, testcase.wordlenum, res.wordlenum)
+			assert.Equal(t, testcase.score, res.score)
+		}
+	}
+}
+
+func TestExtractResult_NoMatch(t *testing.T) {
+	// Connections with a missing row
+	assert.Nil(t, extractResult("Connections\nPuzzle #512\n🟨🟨🟨🟨\n🟩🟩🟩🟩\n🟦🟦🟦🟦"))
+	// Quordle without the boards
+	assert.Nil(t, extractResult("Daily Quordle 1024"))
+	assert.Nil(t, extractResult("I solved the crossword"))
+	// Scores the games can't give
+	assert.Nil(t, extractResult("Wordle 1,283 0/6"))
+	assert.Nil(t, extractResult("nerdlegame 728 8/6"))
+	assert.Nil(t, extractResult("I solved the Monday 10/14/2024 New York Times Mini Crossword in 0:00!"))
+}
+
+func Test_makeSummaryPositionMessage_Connections(t *testing.T) {
+	inputs := []Result{
+		{game: "Connections", score: 4, displayName: "user1", wordlenum: 512},
+		{game: "Connections", score: 0, displayName: "user2", wordlenum: 512},
+		{game: "Connections", score: 1, displayName: "user3", wordlenum: 512},
+	}
+	res := makeSummaryPositionMessage(inputs)
+	expected := "Results for Connections #512:\nperfect: user2\n1 mistake: user3\nfailed: user1\n"
+	assert.Equal(t, expected, res)
+}
+
+func Test_quordle_FailureRanksLast(t *testing.T) {
+	q := quordle{}
+	// The best failure, with three boards solved in one guess each
+	failed := extractResult("Daily Quordle 1024\n1️⃣1️⃣\n1️⃣🟥")
+	require.NotNil(t, failed)
+	assert.False(t, q.Solved(failed.score))
+	assert.Equal(t, "failed", q.ScoreLabel(failed.score))
+	assert.Equal(t, len(q.Buckets())-1, q.Bucket(failed.score))
+
+	// The worst solve, with every board on the last guess
+	solved := extractResult("Daily Quordle 1024\n9️⃣9️⃣\n9️⃣9️⃣")
+	require.NotNil(t, solved)
+	assert.True(t, q.Solved(solved.score))
+	assert.Less(t, solved.score, failed.score)
+	assert.Greater(t, q.Points(solved.score), q.Points(failed.score))
+	assert.Less(t, q.Bucket(solved.score), q.Bucket(failed.score))
+
+	summary := makeSummaryPositionMessage([]Result{
+		{game: "Quordle", score: failed.score, displayName: "user1", wordlenum: 1024},
+		{game: "Quordle", score: solved.score, displayName: "user2", wordlenum: 1024},
+	})
+	assert.Equal(t, "Results for Quordle #1024:\n36 total: user2\nfailed: user1\n", summary)
+}
+
+func Test_registerGame(t *testing.T) {
+	names := []string{}
+	for _, g := range games {
+		names = append(names, g.Name())
+	}
+	assert.Equal(t, []string{"Wordle", "Connections", "Strands", "Mini", "Quordle", "Nerdle"}, names)
+	assert.Panics(t, func() { registerGame(nerdle{}) })
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRangePuzzles is the most puzzles a <from>..<to> leaderboard can cover
+const maxRangePuzzles = 366
+
+// leaderboardPeriod is an inclusive range of puzzles to rank players over.
+// A from of 0 means since the first result we have.
+type leaderboardPeriod struct {
+	title string
+	from  int
+	to    int
+}
+
+func (p leaderboardPeriod) heading(game Game) string {
+	return fmt.Sprintf("%s %s Leaderboard", p.title, game.Name())
+}
+
+// weekPeriod is Sunday to Saturday in the channel, ending at puzzle
+func weekPeriod(s ChannelSettings, game Game, puzzle int) leaderboardPeriod {
+	day := s.dayFor(game, puzzle)
+	return leaderboardPeriod{title: "Weekly", from: puzzle - int(day.Weekday()), to: puzzle}
+}
+
+func monthPeriod(s ChannelSettings, game Game, puzzle int) leaderboardPeriod {
+	day := s.dayFor(game, puzzle)
+	first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	return leaderboardPeriod{title: day.Format("January 2006"), from: s.puzzleToday(game, first), to: puzzle}
+}
+
+func yearPeriod(s ChannelSettings, game Game, puzzle int) leaderboardPeriod {
+	day := s.dayFor(game, puzzle)
+	first := time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, day.Location())
+	return leaderboardPeriod{title: day.Format("2006"), from: s.puzzleToday(game, first), to: puzzle}
+}
+
+func allTimePeriod(puzzle int) leaderboardPeriod {
+	return leaderboardPeriod{title: "All-time", from: 0, to: puzzle}
+}
+
+// parseLeaderboardPeriod understands week, month, year, all and <from>..<to>,
+// where from and to are puzzle numbers or YYYY-MM-DD dates. The calendar
+// periods are the ones containing latest in the channel, and no period goes
+// past it.
+func parseLeaderboardPeriod(s ChannelSettings, game Game, arg string, latest int) (leaderboardPeriod, error) {
+	switch strings.ToLower(arg) {
+	case "week":
+		return weekPeriod(s, game, latest), nil
+	case "month":
+		return monthPeriod(s, game, latest), nil
+	case "year":
+		return yearPeriod(s, game, latest), nil
+	case "all":
+		return allTimePeriod(latest), nil
+	}
+
+	start, end, ok := strings.Cut(arg, "..")
+	if !ok {
+		return leaderboardPeriod{}, fmt.Errorf("I don't know the game or period %q. Try week, month, year, all or <from>..<to>", arg)
+	}
+	from, err := parsePuzzleOrDate(s, game, start)
+	if err != nil {
+		return leaderboardPeriod{}, err
+	}
+	to, err := parsePuzzleOrDate(s, game, end)
+	if err != nil {
+		return leaderboardPeriod{}, err
+	}
+	if from > to {
+		return leaderboardPeriod{}, fmt.Errorf("%q starts after it ends", arg)
+	}
+	if from > latest {
+		return leaderboardPeriod{}, fmt.Errorf("%q starts after the latest puzzle, %s", arg, game.PuzzleTitle(latest))
+	}
+	to = min(to, latest)
+	if to-from >= maxRangePuzzles {
+		return leaderboardPeriod{}, fmt.Errorf("%q is too long. Try a year or less", arg)
+	}
+	title := fmt.Sprintf("%s to %s", s.dayFor(game, from).Format("Jan 2 2006"), s.dayFor(game, to).Format("Jan 2 2006"))
+	return leaderboardPeriod{title: title, from: from, to: to}, nil
+}
+
+// parsePuzzleOrDate reads a puzzle number, or a date in the channel
+func parsePuzzleOrDate(s ChannelSettings, game Game, arg string) (int, error) {
+	if num, err := strconv.Atoi(arg); err == nil {
+		return num, nil
+	}
+	day, err := time.ParseInLocation(dateLayout, arg, s.location)
+	if err != nil {
+		return 0, fmt.Errorf("%q is neither a puzzle number nor a YYYY-MM-DD date", arg)
+	}
+	return s.puzzleToday(game, day), nil
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseLeaderboardPeriod(t *testing.T) {
+	// Thursday December 26th 2024
+	latest := 1286
+
+	tests := []struct {
+		arg  string
+		want leaderboardPeriod
+	}{
+		{"week", leaderboardPeriod{title: "Weekly", from: 1282, to: 1286}},
+		{"Month", leaderboardPeriod{title: "December 2024", from: 1261, to: 1286}},
+		{"year", leaderboardPeriod{title: "2024", from: 926, to: 1286}},
+		{"all", leaderboardPeriod{title: "All-time", from: 0, to: 1286}},
+		{"1200..1210", leaderboardPeriod{title: "Oct 1 2024 to Oct 11 2024", from: 1200, to: 1210}},
+		{"2024-12-01..2024-12-07", leaderboardPeriod{title: "Dec 1 2024 to Dec 7 2024", from: 1261, to: 1267}},
+		{"1280..2000000000", leaderboardPeriod{title: "Dec 20 2024 to Dec 26 2024", from: 1280, to: 1286}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := parseLeaderboardPeriod(defaultChannelSettings(), wordle{}, tt.arg, latest)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, arg := range []string{"fortnight", "1210..1200", "then..now", "1..1286", "1300..1310"} {
+		_, err := parseLeaderboardPeriod(defaultChannelSettings(), wordle{}, arg, latest)
+		assert.Error(t, err, arg)
+	}
+}
+
+func Test_parseLeaderboardPeriod_ChannelTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	settings := defaultChannelSettings()
+	settings.location = tokyo
+
+	// Wednesday January 1st 2025, which Tokyo reaches first
+	got, err := parseLeaderboardPeriod(settings, wordle{}, "month", 1292)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboardPeriod{title: "January 2025", from: 1292, to: 1292}, got)
+	got, err = parseLeaderboardPeriod(settings, wordle{}, "week", 1292)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboardPeriod{title: "Weekly", from: 1289, to: 1292}, got)
+	got, err = parseLeaderboardPeriod(settings, wordle{}, "2025-01-01..2025-01-01", 1292)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboardPeriod{title: "Jan 1 2025 to Jan 1 2025", from: 1292, to: 1292}, got)
+}
+
+func Test_getLeaderBoardPost_BadScores(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1", "userid2"}, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+
+	// Stored before shares were checked
+	for _, res := range []Result{makeResult("userid1", "", 1283, 0), makeResult("userid2", "", 1283, 9)} {
+		res.team, res.channel = "testteam", "testchannel"
+		require.NoError(t, db.putResult(res))
+	}
+
+	post, err := getLeaderBoardPost(db, mockSlack, testScope, wordle{}, weekPeriod(defaultChannelSettings(), wordle{}, 1283))
+	require.NoError(t, err)
+	assert.Contains(t, post.text, "sean")
+	assert.Contains(t, post.text, "lara")
+}
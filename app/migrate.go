@@ -0,0 +1,258 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"wordleturtle/config"
+	"wordleturtle/database"
+)
+
+// Migration is one numbered schema change from the database package
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus reports how far a database has been migrated
+type MigrationStatus struct {
+	Current int
+	Pending []Migration
+}
+
+// MigrationParams are values some migrations need that only the deployment
+// knows. Migrations read them from the migration_params temp table.
+type MigrationParams struct {
+	// LegacyTeam and LegacyChannel own the results recorded before
+	// channels were tracked
+	LegacyTeam    string
+	LegacyChannel string
+}
+
+// legacyRowMigrations are the migrations that give existing rows in some
+// tables to LegacyTeam and LegacyChannel, by version
+var legacyRowMigrations = map[int][]string{
+	5: {"results", "jobs"},
+}
+
+func migrationParamsFromConfig(c *config.BotConfig) MigrationParams {
+	return MigrationParams{
+		LegacyTeam:    c.LegacyTeam,
+		LegacyChannel: c.LegacyChannel,
+	}
+}
+
+// loadMigrations reads the <version>_<name>.sql files in dir, sorted by version
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		versionStr, label, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s isn't named <version>_<name>.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has a bad version: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: label, SQL: string(body)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d_%s is out of sequence", m.Version, m.Name)
+		}
+	}
+	return migrations, nil
+}
+
+type migrator struct {
+	db         *sqlDB
+	migrations []Migration
+	params     MigrationParams
+	// legacyVersion works out how far along a database that predates the
+	// schema_version table is, from the tables and columns it has
+	legacyVersion func() (int, error)
+}
+
+func newMigrator(db *sqlDB, params MigrationParams, legacyVersion func() (int, error)) (*migrator, error) {
+	migrations, err := loadMigrations(database.Migrations, db.dialect.migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &migrator{
+		db:            db,
+		migrations:    migrations,
+		params:        params,
+		legacyVersion: legacyVersion,
+	}, nil
+}
+
+func (m *migrator) hasVersionTable() (bool, error) {
+	row := m.db.queryRow(m.db.dialect.tableExists, "schema_version")
+	var count int
+	err := row.Scan(&count)
+	return count > 0, err
+}
+
+func (m *migrator) status() (MigrationStatus, error) {
+	var status MigrationStatus
+	tracked, err := m.hasVersionTable()
+	if err != nil {
+		return status, err
+	}
+	if tracked {
+		row := m.db.queryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version")
+		err = row.Scan(&status.Current)
+	} else {
+		status.Current, err = m.legacyVersion()
+	}
+	if err != nil {
+		return status, err
+	}
+	for _, migration := range m.migrations {
+		if migration.Version > status.Current {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// up applies every pending migration, each in its own transaction, and
+// returns the ones it applied
+func (m *migrator) up() ([]Migration, error) {
+	status, err := m.status()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.db.exec("CREATE TABLE IF NOT EXISTS schema_version (version INTEGER PRIMARY KEY, name VARCHAR(64), appliedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP)"); err != nil {
+		return nil, err
+	}
+	// Record what a hand-built database already has so it isn't rerun
+	for _, migration := range m.migrations {
+		if migration.Version > status.Current {
+			break
+		}
+		if _, err := m.db.exec("INSERT INTO schema_version(version, name) VALUES( ?, ? ) ON CONFLICT DO NOTHING", migration.Version, migration.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	applied := make([]Migration, 0, len(status.Pending))
+	for _, migration := range status.Pending {
+		if err := m.apply(migration); err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+func (m *migrator) apply(migration Migration) error {
+	tx, err := m.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.checkLegacyParams(tx, migration); err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"legacy_team":    m.params.LegacyTeam,
+		"legacy_channel": m.params.LegacyChannel,
+	}
+	if _, err := tx.Exec("CREATE TEMP TABLE IF NOT EXISTS migration_params (name VARCHAR(64) PRIMARY KEY, value TEXT)"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM migration_params"); err != nil {
+		return err
+	}
+	for name, value := range params {
+		if _, err := tx.Exec(m.db.rebind("INSERT INTO migration_params(name, value) VALUES( ?, ? )"), name, value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(migration.SQL); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(m.db.rebind("INSERT INTO schema_version(version, name) VALUES( ?, ? )"), migration.Version, migration.Name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkLegacyParams refuses to give existing rows to a blank team or
+// channel, where no query would ever find them again
+func (m *migrator) checkLegacyParams(tx *sql.Tx, migration Migration) error {
+	tables, ok := legacyRowMigrations[migration.Version]
+	if !ok || (m.params.LegacyTeam != "" && m.params.LegacyChannel != "") {
+		return nil
+	}
+	for _, table := range tables {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%s has %d rows from before channels were tracked. Set LEGACY_TEAM and LEGACY_CHANNEL to the workspace and channel they belong to", table, count)
+		}
+	}
+	return nil
+}
+
+// openMigrator opens the database configured in c without migrating it
+func openMigrator(c *config.BotConfig) (*migrator, error) {
+	params := migrationParamsFromConfig(c)
+	switch c.DBDriver {
+	case config.DBSQLite:
+		db, err := openSQLiteDB(c.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return db.migrator(params)
+	case config.DBPostgres:
+		db, err := openPostgresDB(c.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return db.migrator(params)
+	}
+	return nil, fmt.Errorf("unknown database driver %q", c.DBDriver)
+}
+
+// GetMigrationStatus reports the configured database's schema version and
+// the migrations that haven't been applied to it yet
+func GetMigrationStatus(c *config.BotConfig) (MigrationStatus, error) {
+	m, err := openMigrator(c)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	defer m.db.db.Close()
+	return m.status()
+}
+
+// Migrate applies any pending migrations to the configured database
+func Migrate(c *config.BotConfig) ([]Migration, error) {
+	m, err := openMigrator(c)
+	if err != nil {
+		return nil, err
+	}
+	defer m.db.db.Close()
+	return m.up()
+}
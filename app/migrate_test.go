@@ -0,0 +1,97 @@
+package app
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"wordleturtle/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_migrate_Fresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wordles")
+	db, err := NewSQLiteDB(path, MigrationParams{})
+	require.NoError(t, err)
+
+	m, err := db.migrator(MigrationParams{})
+	require.NoError(t, err)
+	status, err := m.status()
+	require.NoError(t, err)
+	assert.Equal(t, len(m.migrations), status.Current)
+	assert.Empty(t, status.Pending)
+
+	// Opening again is a no-op
+	_, err = NewSQLiteDB(path, MigrationParams{})
+	assert.NoError(t, err)
+
+	res := makeResult("userid1", "sean", 917, 3)
+	res.team, res.channel = "testteam", "testchannel"
+	res.grid = []string{"-Y---", "GG-Y-", "GGGGG"}
+	require.NoError(t, db.putResult(res))
+	dailies, err := db.getDailyResults(testScope, "Wordle", 917)
+	require.NoError(t, err)
+	assert.Equal(t, []Result{res}, dailies)
+}
+
+func Test_migrate_Legacy(t *testing.T) {
+	// A database built by hand from the original init script
+	path := filepath.Join(t.TempDir(), "wordles")
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	migrations, err := loadMigrations(database.Migrations, "sqlite")
+	require.NoError(t, err)
+	_, err = raw.Exec(migrations[0].SQL)
+	require.NoError(t, err)
+	_, err = raw.Exec("INSERT INTO results(wordlenum, userId, displayName, score, hardmode) VALUES(917, 'userid1', 'sean', 3, 1)")
+	require.NoError(t, err)
+	raw.Close()
+
+	db, err := openSQLiteDB(path)
+	require.NoError(t, err)
+
+	// The old results would be lost without knowing where they belong
+	m, err := db.migrator(MigrationParams{LegacyTeam: "testteam"})
+	require.NoError(t, err)
+	_, err = m.up()
+	assert.ErrorContains(t, err, "LEGACY_CHANNEL")
+	status, err := m.status()
+	require.NoError(t, err)
+	assert.Equal(t, 4, status.Current)
+
+	m, err = db.migrator(MigrationParams{LegacyTeam: "testteam", LegacyChannel: "testchannel"})
+	require.NoError(t, err)
+	status, err = m.status()
+	require.NoError(t, err)
+	assert.Equal(t, 4, status.Current)
+
+	applied, err := m.up()
+	require.NoError(t, err)
+	assert.Len(t, applied, len(m.migrations)-4)
+
+	dailies, err := db.getDailyResults(testScope, "Wordle", 917)
+	require.NoError(t, err)
+	if assert.Len(t, dailies, 1) {
+		assert.Equal(t, "sean", dailies[0].displayName)
+		assert.Equal(t, 1, dailies[0].hardmode)
+	}
+}
+
+func Test_loadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sqlite/0002_second.sql": {Data: []byte("SELECT 2;")},
+		"sqlite/0001_first.sql":  {Data: []byte("SELECT 1;")},
+	}
+	migrations, err := loadMigrations(fsys, "sqlite")
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "first", SQL: "SELECT 1;"},
+		{Version: 2, Name: "second", SQL: "SELECT 2;"},
+	}, migrations)
+
+	fsys["sqlite/0004_skipped.sql"] = &fstest.MapFile{Data: []byte("SELECT 4;")}
+	_, err = loadMigrations(fsys, "sqlite")
+	assert.Error(t, err)
+}
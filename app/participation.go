@@ -0,0 +1,181 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// participationState is whether someone in a channel plays
+type participationState string
+
+const (
+	// stateActive players are expected to play every day
+	stateActive participationState = ""
+	// stateVacation players aren't expected to play until after a date
+	stateVacation participationState = "vacation"
+	// stateSpectator players aren't expected to play, but their results count
+	stateSpectator participationState = "spectator"
+	// stateOptedOut players aren't expected to play, and their results don't
+	// count
+	stateOptedOut participationState = "optedout"
+)
+
+// dateLayout is how vacation dates are written and stored
+const dateLayout = "2006-01-02"
+
+// Participation is whether a player is active, on vacation, etc. The zero
+// value is active.
+type Participation struct {
+	state participationState
+	// from and until are the first and last days of a vacation, as
+	// YYYY-MM-DD. Vacations from before from was recorded have none.
+	from  string
+	until string
+}
+
+// expected reports whether the player should play on day, so is missing or
+// a turkey if they don't
+func (p Participation) expected(day time.Time) bool {
+	switch p.state {
+	case stateVacation:
+		date := day.Format(dateLayout)
+		return date < p.from || date > p.until
+	case stateSpectator, stateOptedOut:
+		return false
+	}
+	return true
+}
+
+func (p Participation) String() string {
+	switch p.state {
+	case stateVacation:
+		until, err := time.Parse(dateLayout, p.until)
+		if err != nil {
+			return "on vacation"
+		}
+		return "on vacation until " + until.Format("Jan 2")
+	case stateSpectator:
+		return "spectating"
+	case stateOptedOut:
+		return "opted out"
+	}
+	return "playing"
+}
+
+// expectedPlayers filters users down to the ones who should play on day
+func expectedPlayers(users []string, players map[string]Participation, day time.Time) []string {
+	expected := make([]string, 0, len(users))
+	for _, u := range users {
+		if players[u].expected(day) {
+			expected = append(expected, u)
+		}
+	}
+	return expected
+}
+
+// parseVacationEnd reads the last day of a vacation, like 2025-01-05, or 7d
+// or 2w from today
+func parseVacationEnd(arg string, today time.Time) (string, error) {
+	if until, err := time.Parse(dateLayout, arg); err == nil {
+		if until.Format(dateLayout) < today.Format(dateLayout) {
+			return "", fmt.Errorf("%s has already been", arg)
+		}
+		return until.Format(dateLayout), nil
+	}
+	days := 0
+	if n, ok := strings.CutSuffix(arg, "d"); ok {
+		days, _ = strconv.Atoi(n)
+	} else if n, ok := strings.CutSuffix(arg, "w"); ok {
+		weeks, _ := strconv.Atoi(n)
+		days = 7 * weeks
+	}
+	if days <= 0 {
+		return "", fmt.Errorf("I don't understand %q. Try a date like 2025-01-05, or 7d or 2w", arg)
+	}
+	return today.AddDate(0, 0, days).Format(dateLayout), nil
+}
+
+// setParticipation changes the participation of the "user" argument,
+// defaulting to the sender. Only admins can change other people's.
+func (h *HTTPHandler) setParticipation(sm ChatMessage, args commandArgs, p Participation) error {
+	userId := args.user(sm.user)
+	if userId != sm.user {
+		admin, err := h.isAdmin(sm.scope(), sm.user)
+		if err != nil {
+			return err
+		}
+		if !admin {
+			return h.chat.PostMessage(sm.channel, "Only admins can change whether someone else is playing")
+		}
+	}
+	name, err := h.chat.NameForUser(userId)
+	if err != nil {
+		return err
+	}
+	if err := h.db.putParticipation(sm.scope(), userId, p); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%s is now %s", name, p)
+	if p.state == stateOptedOut {
+		msg += fmt.Sprintf(", so I won't count their results. %s optin to play again", botName)
+	}
+	return h.chat.PostMessage(sm.channel, msg)
+}
+
+func (h *HTTPHandler) handleVacation(sm ChatMessage, args commandArgs) error {
+	settings, err := h.db.getChannelSettings(sm.scope())
+	if err != nil {
+		return err
+	}
+	today := h.now().In(settings.location)
+	until, err := parseVacationEnd(args.get("until"), today)
+	if err != nil {
+		return h.chat.PostMessage(sm.channel, err.Error())
+	}
+	return h.setParticipation(sm, args, Participation{state: stateVacation, from: today.Format(dateLayout), until: until})
+}
+
+func (h *HTTPHandler) handleSpectate(sm ChatMessage, args commandArgs) error {
+	return h.setParticipation(sm, args, Participation{state: stateSpectator})
+}
+
+func (h *HTTPHandler) handleOptOut(sm ChatMessage, args commandArgs) error {
+	return h.setParticipation(sm, args, Participation{state: stateOptedOut})
+}
+
+func (h *HTTPHandler) handleOptIn(sm ChatMessage, args commandArgs) error {
+	return h.setParticipation(sm, args, Participation{state: stateActive})
+}
+
+// handlePlayers lists the people who aren't playing every day
+func (h *HTTPHandler) handlePlayers(sm ChatMessage, args commandArgs) error {
+	players, err := h.db.getParticipation(sm.scope())
+	if err != nil {
+		return err
+	}
+	settings, err := h.db.getChannelSettings(sm.scope())
+	if err != nil {
+		return err
+	}
+	today := h.now().In(settings.location)
+	lines := make([]string, 0, len(players))
+	for userId, p := range players {
+		if p.state == stateVacation && p.expected(today) {
+			// Back already
+			continue
+		}
+		name, err := h.chat.NameForUser(userId)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, p))
+	}
+	if len(lines) == 0 {
+		return h.chat.PostMessage(sm.channel, "Everyone is playing")
+	}
+	sort.Strings(lines)
+	return h.chat.PostMessage(sm.channel, "Not playing every day:\n"+strings.Join(lines, "\n"))
+}
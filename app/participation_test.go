@@ -0,0 +1,167 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Participation_expected(t *testing.T) {
+	day := DayForWordle(1283)
+	assert.True(t, Participation{}.expected(day))
+	assert.False(t, Participation{state: stateVacation, until: "2024-12-23"}.expected(day))
+	assert.True(t, Participation{state: stateVacation, until: "2024-12-22"}.expected(day))
+	assert.True(t, Participation{state: stateVacation, from: "2024-12-24", until: "2024-12-26"}.expected(day))
+	assert.False(t, Participation{state: stateSpectator}.expected(day))
+	assert.False(t, Participation{state: stateOptedOut}.expected(day))
+
+	players := map[string]Participation{"userid2": {state: stateSpectator}}
+	assert.Equal(t, []string{"userid1", "userid3"}, expectedPlayers([]string{"userid1", "userid2", "userid3"}, players, day))
+}
+
+func Test_parseVacationEnd(t *testing.T) {
+	today := DayForWordle(1283)
+	tests := []struct {
+		arg   string
+		until string
+		ok    bool
+	}{
+		{"2025-01-05", "2025-01-05", true},
+		{"2024-12-23", "2024-12-23", true},
+		{"7d", "2024-12-30", true},
+		{"2w", "2025-01-06", true},
+		{"2024-12-01", "", false},
+		{"0d", "", false},
+		{"soon", "", false},
+	}
+	for _, tt := range tests {
+		until, err := parseVacationEnd(tt.arg, today)
+		if !tt.ok {
+			assert.Error(t, err, tt.arg)
+			continue
+		}
+		require.NoError(t, err, tt.arg)
+		assert.Equal(t, tt.until, until, tt.arg)
+	}
+}
+
+func Test_participationCommands(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	morning := DayForWordle(1283).Add(9 * time.Hour)
+	h := &HTTPHandler{db: db, chat: mockSlack, clock: newFakeClock(morning)}
+	command := func(user, text string) error {
+		fields := strings.Fields(text)
+		return h.handleCommand(ChatMessage{team: "testteam", channel: "testchannel", user: user}, fields[0], fields[1:])
+	}
+
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	mockSlack.On("NameForUser", "userid2").Return("lara", nil)
+	mockSlack.On("NameForUser", "userid3").Return("pat", nil)
+	mockSlack.On("IsAdmin", "testchannel", "userid1").Return(true, nil)
+	mockSlack.On("IsAdmin", "testchannel", "userid2").Return(false, nil)
+
+	mockSlack.On("PostMessage", "testchannel", "Everyone is playing").Return(nil).Once()
+	require.NoError(t, command("userid2", "players"))
+	mockSlack.On("PostMessage", "testchannel", "lara is now on vacation until Dec 30").Return(nil).Once()
+	require.NoError(t, command("userid2", "vacation 7d"))
+	mockSlack.On("PostMessage", "testchannel", "Only admins can change whether someone else is playing").Return(nil).Once()
+	require.NoError(t, command("userid2", "spectate <@userid3>"))
+	mockSlack.On("PostMessage", "testchannel", "pat is now spectating").Return(nil).Once()
+	require.NoError(t, command("userid1", "spectate <@userid3>"))
+	mockSlack.On("PostMessage", "testchannel", "sean is now opted out, so I won't count their results. WordleTurtle optin to play again").Return(nil).Once()
+	require.NoError(t, command("userid1", "optout"))
+	mockSlack.On("PostMessage", "testchannel", "Not playing every day:\nlara: on vacation until Dec 30\npat: spectating\nsean: opted out").Return(nil).Once()
+	require.NoError(t, command("userid2", "players"))
+
+	// Opted out results are turned away
+	mockSlack.On("PostThreadReply", "testchannel", "1700000000.000100", "You've opted out, so I'm not counting this. WordleTurtle optin to play again").Return(nil).Once()
+	require.NoError(t, h.handleUserMessage(ChatMessage{team: "testteam", channel: "testchannel", user: "userid1", text: "Wordle 1,283 3/6", ts: "1700000000.000100"}))
+	dailies, err := db.getDailyResults(testScope, "Wordle", 1283)
+	require.NoError(t, err)
+	assert.Empty(t, dailies)
+
+	mockSlack.On("PostMessage", "testchannel", "sean is now playing").Return(nil).Once()
+	require.NoError(t, command("userid1", "optin"))
+	mockSlack.AssertExpectations(t)
+
+	players, err := db.getParticipation(testScope)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Participation{
+		"userid2": {state: stateVacation, from: "2024-12-23", until: "2024-12-30"},
+		"userid3": {state: stateSpectator},
+	}, players)
+}
+
+func Test_handlePlayers_ChannelTimezone(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	settings := defaultChannelSettings()
+	settings.location = tokyo
+	require.NoError(t, db.putChannelSettings(testScope, settings))
+
+	// Still the 23rd in Los Angeles, but lara's vacation is over in Tokyo
+	h := &HTTPHandler{db: db, chat: mockSlack, clock: newFakeClock(DayForWordle(1283).Add(20 * time.Hour))}
+	require.NoError(t, db.putParticipation(testScope, "userid2", Participation{state: stateVacation, from: "2024-12-20", until: "2024-12-23"}))
+	mockSlack.On("PostMessage", "testchannel", "Everyone is playing").Return(nil).Once()
+	require.NoError(t, h.handleCommand(ChatMessage{team: "testteam", channel: "testchannel", user: "userid2"}, "players", nil))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_getLeaderBoardPost_Participation(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1", "userid2", "userid3", "userid4", "userid5"}, nil)
+	for id, name := range map[string]string{"userid1": "sean", "userid2": "lara", "userid3": "pat", "userid4": "kim", "userid5": "eve"} {
+		mockSlack.On("NameForUser", id).Return(name, nil)
+	}
+	for _, id := range []string{"userid1", "userid4"} {
+		res := makeResult(id, "", 1283, 3)
+		res.team, res.channel = "testteam", "testchannel"
+		require.NoError(t, db.putResult(res))
+	}
+	require.NoError(t, db.putParticipation(testScope, "userid2", Participation{state: stateVacation, until: "2024-12-28"}))
+	require.NoError(t, db.putParticipation(testScope, "userid3", Participation{state: stateSpectator}))
+	require.NoError(t, db.putParticipation(testScope, "userid4", Participation{state: stateOptedOut}))
+
+	post, err := getLeaderBoardPost(db, mockSlack, testScope, wordle{}, weekPeriod(defaultChannelSettings(), wordle{}, 1288))
+	require.NoError(t, err)
+	// Only eve was meant to be playing, and kim's result doesn't count
+	assert.Contains(t, post.text, ":turkey: eve forgot to show up!")
+	assert.Contains(t, post.text, "sean")
+	assert.NotContains(t, post.text, "kim")
+}
+
+func Test_getLeaderBoardPost_PartialVacation(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1", "userid2", "userid3"}, nil)
+	for id, name := range map[string]string{"userid1": "sean", "userid2": "lara", "userid3": "pat"} {
+		mockSlack.On("NameForUser", id).Return(name, nil)
+	}
+	// lara is away Tuesday to Thursday, and plays on Wednesday anyway
+	require.NoError(t, db.putParticipation(testScope, "userid2", Participation{state: stateVacation, from: "2024-12-24", until: "2024-12-26"}))
+	for _, res := range []Result{makeResult("userid1", "", 1283, 3), makeResult("userid2", "", 1285, 4)} {
+		res.team, res.channel = "testteam", "testchannel"
+		require.NoError(t, db.putResult(res))
+	}
+
+	post, err := getLeaderBoardPost(db, mockSlack, testScope, wordle{}, weekPeriod(defaultChannelSettings(), wordle{}, 1288))
+	require.NoError(t, err)
+	// Sunday to Saturday is seven days for sean, but only four for lara
+	assert.Contains(t, post.text, "| sean   |     5 |  0 |  0 |  1 |  0 |  0 |  0 |  0 |      6 |")
+	assert.Contains(t, post.text, "| lara   |     4 |  0 |  0 |  0 |  1 |  0 |  0 |  0 |      4 |")
+	assert.Contains(t, post.text, ":turkey: pat forgot to show up!")
+}
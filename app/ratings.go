@@ -0,0 +1,289 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// Glicko-2 constants, see http://www.glicko.net/glicko/glicko2.pdf
+const (
+	glickoScale       = 173.7178
+	glickoTau         = 0.5
+	glickoEpsilon     = 0.000001
+	defaultRating     = 1500.0
+	defaultRD         = 350.0
+	defaultVolatility = 0.06
+)
+
+// Rating is a player's Glicko-2 rating for a game in a channel. Each day's
+// results are a rating period, where everyone who played has beaten, tied
+// or lost to everyone else who played.
+type Rating struct {
+	userId     string
+	rating     float64
+	rd         float64
+	volatility float64
+	// wordlenum is the last puzzle the rating was updated for
+	wordlenum int
+}
+
+func newRating(userId string) Rating {
+	return Rating{userId: userId, rating: defaultRating, rd: defaultRD, volatility: defaultVolatility}
+}
+
+// glickoOutcome is one game against an opponent: 1 for a win, 0.5 for a
+// draw and 0 for a loss
+type glickoOutcome struct {
+	opponent Rating
+	score    float64
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glickoE(mu, muj, phij float64) float64 {
+	return 1 / (1 + math.Exp(-glickoG(phij)*(mu-muj)))
+}
+
+// update applies one rating period's outcomes
+func (r Rating) update(outcomes []glickoOutcome) Rating {
+	mu := (r.rating - defaultRating) / glickoScale
+	phi := r.rd / glickoScale
+	sigma := r.volatility
+
+	// Sitting out a period only makes us less sure of the rating
+	if len(outcomes) == 0 {
+		r.rd = math.Min(math.Sqrt(phi*phi+sigma*sigma)*glickoScale, defaultRD)
+		return r
+	}
+
+	var vInv, sum float64
+	for _, o := range outcomes {
+		muj := (o.opponent.rating - defaultRating) / glickoScale
+		phij := o.opponent.rd / glickoScale
+		g := glickoG(phij)
+		e := glickoE(mu, muj, phij)
+		vInv += g * g * e * (1 - e)
+		sum += g * (o.score - e)
+	}
+	v := 1 / vInv
+	delta := v * sum
+
+	// Find the new volatility with the Illinois algorithm
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(glickoTau*glickoTau)
+	}
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		B = a - k*glickoTau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glickoEpsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	newSigma := math.Exp(A / 2)
+
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*sum
+
+	r.rating = newMu*glickoScale + defaultRating
+	r.rd = newPhi * glickoScale
+	r.volatility = newSigma
+	return r
+}
+
+// rateDay works out everyone's new ratings after a day's results. Players
+// without a rating start at the default. If the day has already been rated
+// nothing changes.
+func rateDay(game Game, ratings []Rating, dailies []Result, wordlenum int) []Rating {
+	current := make(map[string]Rating)
+	for _, r := range ratings {
+		if r.wordlenum >= wordlenum {
+			return nil
+		}
+		current[r.userId] = r
+	}
+	for _, res := range dailies {
+		if _, ok := current[res.userId]; !ok {
+			current[res.userId] = newRating(res.userId)
+		}
+	}
+
+	outcomes := make(map[string][]glickoOutcome)
+	for _, res := range dailies {
+		for _, other := range dailies {
+			if res.userId == other.userId {
+				continue
+			}
+			score := 0.5
+			if game.Points(res.score) > game.Points(other.score) {
+				score = 1
+			} else if game.Points(res.score) < game.Points(other.score) {
+				score = 0
+			}
+			outcomes[res.userId] = append(outcomes[res.userId], glickoOutcome{opponent: current[other.userId], score: score})
+		}
+	}
+
+	updated := make([]Rating, 0, len(current))
+	for userId, r := range current {
+		r = r.update(outcomes[userId])
+		r.wordlenum = wordlenum
+		updated = append(updated, r)
+	}
+	sortRatings(updated)
+	return updated
+}
+
+func sortRatings(ratings []Rating) {
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].rating == ratings[j].rating {
+			return ratings[i].userId < ratings[j].userId
+		}
+		return ratings[i].rating > ratings[j].rating
+	})
+}
+
+// updateRatings rates a finished day and returns a line for the final
+// summary with the changes for everyone who played. A day that's already
+// rated, e.g. when the final summary failed to post and is being retried,
+// isn't rated again, but its line is worked out from the rating history.
+func updateRatings(db DB, sc scope, game Game, wordlenum int, dailies []Result) (string, error) {
+	rated, err := db.getRatingsBefore(sc, game.Name(), wordlenum+1)
+	if err != nil {
+		return "", err
+	}
+	var ratings, updated []Rating
+	if len(rated) > 0 && rated[0].wordlenum == wordlenum {
+		if ratings, err = db.getRatingsBefore(sc, game.Name(), wordlenum); err != nil {
+			return "", err
+		}
+		updated = rated
+		sortRatings(updated)
+	} else {
+		if ratings, err = db.getRatings(sc, game.Name()); err != nil {
+			return "", err
+		}
+		updated = rateDay(game, ratings, dailies, wordlenum)
+		for _, r := range updated {
+			if err := db.putRating(sc, game.Name(), r); err != nil {
+				return "", err
+			}
+		}
+	}
+	before := make(map[string]float64)
+	for _, r := range ratings {
+		before[r.userId] = r.rating
+	}
+
+	played := make(map[string]string)
+	for _, res := range dailies {
+		played[res.userId] = res.displayName
+	}
+	changes := []string{}
+	for _, r := range updated {
+		name, ok := played[r.userId]
+		if !ok {
+			continue
+		}
+		old, ok := before[r.userId]
+		if !ok {
+			old = defaultRating
+		}
+		changes = append(changes, fmt.Sprintf("%s %.0f (%+.0f)", name, r.rating, r.rating-old))
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	return ":chart_with_upwards_trend: Ratings: " + strings.Join(changes, ", "), nil
+}
+
+// rerateFrom redoes the ratings for every rated day from wordlenum on, after
+// an admin changed a result for wordlenum. Days rated before rating history
+// was kept can't be redone.
+func rerateFrom(db DB, sc scope, game Game, wordlenum int) error {
+	puzzles, err := db.getRatedPuzzles(sc, game.Name(), wordlenum)
+	if err != nil {
+		return err
+	}
+	if len(puzzles) == 0 {
+		// Not rated yet, so the end of the day will see the change
+		return nil
+	}
+	seeded, err := db.getLastSeededPuzzle(sc, game.Name())
+	if err != nil {
+		return err
+	}
+	if wordlenum <= seeded {
+		log.Printf("Can't redo %s ratings in %s from %s, which is before rating history", game.Name(), sc.channel, game.PuzzleTitle(wordlenum))
+		return nil
+	}
+
+	ratings, err := db.getRatingsBefore(sc, game.Name(), wordlenum)
+	if err != nil {
+		return err
+	}
+	if err := db.deleteRatingsFrom(sc, game.Name(), wordlenum); err != nil {
+		return err
+	}
+	for _, num := range puzzles {
+		dailies, err := db.getDailyResults(sc, game.Name(), num)
+		if err != nil {
+			return err
+		}
+		ratings = rateDay(game, ratings, dailies, num)
+		for _, r := range ratings {
+			if err := db.putRating(sc, game.Name(), r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getRatingsPost(chat ChatConnection, game Game, ratings []Rating) (string, error) {
+	if len(ratings) == 0 {
+		return fmt.Sprintf("Nobody has a %s rating yet", game.Name()), nil
+	}
+	sortRatings(ratings)
+
+	tw := table.NewWriter()
+	tw.AppendHeader(table.Row{"Player", "Rating", "±"})
+	for _, r := range ratings {
+		player, err := chat.NameForUser(r.userId)
+		if err != nil {
+			return "", err
+		}
+		// Twice the RD gives a 95% confidence interval
+		tw.AppendRow(table.Row{player, fmt.Sprintf("%.0f", r.rating), fmt.Sprintf("%.0f", 2*r.rd)})
+	}
+	tw.Style().Format = table.FormatOptions{
+		Header: text.FormatDefault,
+	}
+	return fmt.Sprintf("%s Ratings\n", game.Name()) + "```\n" + tw.Render() + "\n```", nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Rating_update(t *testing.T) {
+	// The worked example from Glickman's Glicko-2 paper
+	r := Rating{userId: "userid1", rating: 1500, rd: 200, volatility: 0.06}
+	updated := r.update([]glickoOutcome{
+		{opponent: Rating{rating: 1400, rd: 30}, score: 1},
+		{opponent: Rating{rating: 1550, rd: 100}, score: 0},
+		{opponent: Rating{rating: 1700, rd: 300}, score: 0},
+	})
+	assert.InDelta(t, 1464.06, updated.rating, 0.01)
+	assert.InDelta(t, 151.52, updated.rd, 0.01)
+	assert.InDelta(t, 0.05999, updated.volatility, 0.00001)
+
+	// Sitting out only grows the deviation
+	idle := r.update(nil)
+	assert.Equal(t, r.rating, idle.rating)
+	assert.InDelta(t, 200.27, idle.rd, 0.01)
+}
+
+func Test_rateDay(t *testing.T) {
+	ratings := []Rating{{userId: "userid3", rating: 1500, rd: 100, volatility: 0.06, wordlenum: 916}}
+	dailies := []Result{
+		makeResult("userid1", "sean", 917, 3),
+		makeResult("userid2", "lara", 917, 5),
+	}
+
+	updated := rateDay(wordle{}, ratings, dailies, 917)
+	require.Len(t, updated, 3)
+	assert.Equal(t, "userid1", updated[0].userId)
+	assert.Greater(t, updated[0].rating, defaultRating)
+	assert.Equal(t, "userid3", updated[1].userId)
+	assert.Equal(t, 1500.0, updated[1].rating)
+	assert.Greater(t, updated[1].rd, 100.0)
+	assert.Equal(t, "userid2", updated[2].userId)
+	assert.Less(t, updated[2].rating, defaultRating)
+	for _, r := range updated {
+		assert.Equal(t, 917, r.wordlenum)
+	}
+
+	// Rating the same day twice does nothing
+	assert.Empty(t, rateDay(wordle{}, updated, dailies, 917))
+}
+
+func Test_updateRatings(t *testing.T) {
+	mockDb := new(MockDB)
+	dailies := []Result{
+		makeResult("userid1", "sean", 917, 3),
+		makeResult("userid2", "lara", 917, 3),
+	}
+	mockDb.On("getRatingsBefore", testScope, "Wordle", 918).Return([]Rating{}, nil)
+	mockDb.On("getRatings", testScope, "Wordle").Return([]Rating{}, nil)
+	mockDb.On("putRating", testScope, "Wordle", mock.Anything).Return(nil)
+
+	msg, err := updateRatings(mockDb, testScope, wordle{}, 917, dailies)
+	require.NoError(t, err)
+	// A draw between new players changes nothing
+	assert.Equal(t, ":chart_with_upwards_trend: Ratings: sean 1500 (+0), lara 1500 (+0)", msg)
+	mockDb.AssertNumberOfCalls(t, "putRating", 2)
+}
+
+func Test_updateRatings_Retried(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	dailies := []Result{
+		makeResult("userid1", "sean", 917, 3),
+		makeResult("userid2", "lara", 917, 4),
+	}
+
+	msg, err := updateRatings(db, testScope, wordle{}, 917, dailies)
+	require.NoError(t, err)
+	ratings, err := db.getRatings(testScope, "Wordle")
+	require.NoError(t, err)
+
+	// Rating the day again gives the same line and leaves the ratings be
+	again, err := updateRatings(db, testScope, wordle{}, 917, dailies)
+	require.NoError(t, err)
+	assert.Equal(t, msg, again)
+	assert.Contains(t, again, "sean 1662 (+162)")
+	after, err := db.getRatings(testScope, "Wordle")
+	require.NoError(t, err)
+	assert.Equal(t, ratings, after)
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// rejectionsShown is how many rejections the rejections command lists
+const rejectionsShown = 10
+
+// Rejection is a result we refused to count, kept for admins to review
+type Rejection struct {
+	result     Result
+	reason     string
+	rejectedAt time.Time
+}
+
+// checkResult makes sure a result is for a puzzle being played in the
+// channel, give or take its grace. If it isn't, the rejection is recorded,
+// the poster is told why in a thread, and checkResult returns false. Results
+// from players who opted out are turned away too, but not recorded.
+func (h *HTTPHandler) checkResult(sm ChatMessage, res *Result) (bool, error) {
+	players, err := h.db.getParticipation(res.scope())
+	if err != nil {
+		return false, err
+	}
+	if players[res.userId].state == stateOptedOut {
+		return false, h.chat.PostThreadReply(sm.channel, sm.ts, fmt.Sprintf("You've opted out, so I'm not counting this. %s optin to play again", botName))
+	}
+
+	settings, err := h.db.getChannelSettings(res.scope())
+	if err != nil {
+		return false, err
+	}
+	// Judge the puzzle by when it was shared, not when we got to it, which
+	// may be much later for queued or retried events
+	now := h.now()
+	sharedAt := sm.postedAt
+	if sharedAt.IsZero() {
+		sharedAt = now
+	}
+	reason := settings.checkPuzzle(gameFor(*res), res.wordlenum, sharedAt)
+	if reason == nil {
+		return true, nil
+	}
+
+	log.Printf("Rejecting %s %d from %s: %v", res.game, res.wordlenum, res.userId, reason)
+	if err := h.db.putRejection(Rejection{result: *res, reason: reason.Error(), rejectedAt: now}); err != nil {
+		return false, err
+	}
+	return false, h.chat.PostThreadReply(sm.channel, sm.ts, reason.Error())
+}
+
+// handleRejections lists the channel's recent rejections
+func (h *HTTPHandler) handleRejections(sm ChatMessage, args commandArgs) error {
+	rejections, err := h.db.getRejections(sm.scope(), rejectionsShown)
+	if err != nil {
+		return err
+	}
+	if len(rejections) == 0 {
+		return h.chat.PostMessage(sm.channel, "No results have been rejected")
+	}
+	msg := "Recently rejected results:"
+	for _, r := range rejections {
+		game := gameFor(r.result)
+		msg += fmt.Sprintf("\n%s %s: %s %s - %s", r.rejectedAt.Format("Jan 2 15:04"), r.result.displayName,
+			game.PuzzleTitle(r.result.wordlenum), game.ScoreLabel(r.result.score), r.reason)
+	}
+	return h.chat.PostMessage(sm.channel, msg)
+}
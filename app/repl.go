@@ -0,0 +1,178 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	replTeam    = "repl"
+	replChannel = "repl"
+	replHelp    = `Type "<name>: <message>" to post as name, e.g.
+  sean: Wordle 1,283 3/6*
+  sean: WordleTurtle leaderboard
+  sean: <@WordleTurtle> stats
+Use \n for a new line. Other commands:
+  /join <name>        add someone to the channel without posting
+  /time               show the fake clock
+  /advance <duration> move the clock forward (e.g. 2h, 30m) and run due posts
+  /quit               leave`
+)
+
+// replConnection is a ChatConnection that prints the bot's posts. Users
+// are named by their ids, and everyone who has posted is in the channel.
+type replConnection struct {
+	out    io.Writer
+	users  []string
+	nextTs int
+}
+
+func (c *replConnection) NameForUser(userId string) (string, error) {
+	return userId, nil
+}
+
+func (c *replConnection) join(userId string) {
+	for _, u := range c.users {
+		if u == userId {
+			return
+		}
+	}
+	c.users = append(c.users, userId)
+}
+
+func (c *replConnection) newTs() string {
+	c.nextTs++
+	return strconv.Itoa(c.nextTs)
+}
+
+func (c *replConnection) PostMessage(channel, msg string) error {
+	fmt.Fprintf(c.out, "WordleTurtle: %s\n", msg)
+	return nil
+}
+
+func (c *replConnection) PostRichMessage(channel string, msg RichMessage) (string, error) {
+	ts := c.newTs()
+	fmt.Fprintf(c.out, "WordleTurtle [%s]: %s\n", ts, msg.text)
+	return ts, nil
+}
+
+func (c *replConnection) UpdateRichMessage(channel, ts string, msg RichMessage) error {
+	fmt.Fprintf(c.out, "WordleTurtle [%s, edited]: %s\n", ts, msg.text)
+	return nil
+}
+
+func (c *replConnection) PostThreadReply(channel, threadTs, msg string) error {
+	fmt.Fprintf(c.out, "  WordleTurtle [in reply to %s]: %s\n", threadTs, msg)
+	return nil
+}
+
+func (c *replConnection) GetUsers(channel string) ([]string, error) {
+	return c.users, nil
+}
+
+func (c *replConnection) BotUserID() (string, error) {
+	return botName, nil
+}
+
+// IsAdmin is true for everyone, since it's your own terminal
+func (c *replConnection) IsAdmin(channel, userId string) (bool, error) {
+	return true, nil
+}
+
+// REPL drives the bot from a terminal with simulated users and a fake
+// clock, so changes can be tried out without a Slack app
+type REPL struct {
+	bot   *HTTPHandler
+	chat  *replConnection
+	clock *fakeClock
+}
+
+// NewREPL creates a REPL keeping results in db, printing to out, with the
+// clock starting at now
+func NewREPL(db DB, out io.Writer, now time.Time) *REPL {
+	chat := &replConnection{out: out}
+	clock := newFakeClock(now)
+	return &REPL{bot: &HTTPHandler{db: db, chat: chat, clock: clock}, chat: chat, clock: clock}
+}
+
+// Run reads lines from in until it ends or the user quits
+func (r *REPL) Run(in io.Reader) error {
+	fmt.Fprintln(r.chat.out, replHelp)
+	r.printTime()
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(r.chat.out, "> ")
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "/quit" {
+			return nil
+		}
+		if err := r.exec(line); err != nil {
+			fmt.Fprintf(r.chat.out, "Error: %v\n", err)
+		}
+	}
+}
+
+func (r *REPL) exec(line string) error {
+	if line == "" {
+		return nil
+	}
+	if strings.HasPrefix(line, "/") {
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "/help":
+			fmt.Fprintln(r.chat.out, replHelp)
+		case "/join":
+			if len(fields) != 2 {
+				return fmt.Errorf("usage: /join <name>")
+			}
+			r.chat.join(fields[1])
+		case "/time":
+			r.printTime()
+		case "/advance":
+			if len(fields) != 2 {
+				return fmt.Errorf("usage: /advance <duration>")
+			}
+			d, err := time.ParseDuration(fields[1])
+			if err != nil {
+				return err
+			}
+			return r.advance(d)
+		default:
+			return fmt.Errorf("unknown command %s, try /help", fields[0])
+		}
+		return nil
+	}
+
+	user, text, ok := strings.Cut(line, ":")
+	user = strings.TrimSpace(user)
+	if !ok || user == "" || strings.ContainsAny(user, " \t") {
+		return fmt.Errorf(`messages look like "<name>: <message>"`)
+	}
+	r.chat.join(user)
+	return r.bot.handleUserMessage(ChatMessage{
+		team:    replTeam,
+		channel: replChannel,
+		user:    user,
+		text:    strings.ReplaceAll(strings.TrimSpace(text), `\n`, "\n"),
+		ts:      r.chat.newTs(),
+	})
+}
+
+// advance moves the clock forward, running anything that came due
+func (r *REPL) advance(d time.Duration) error {
+	now := r.clock.Advance(d)
+	r.printTime()
+	return r.bot.runDueJobs(now)
+}
+
+func (r *REPL) printTime() {
+	now := r.clock.Now()
+	fmt.Fprintf(r.chat.out, "It's %s, %s\n", now.Format("Mon Jan 2 15:04 MST"), wordle{}.PuzzleTitle(WordleForDay(now)))
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_REPL(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+
+	today := 1283
+	morning := DayForWordle(today).Add(9 * time.Hour)
+	var out strings.Builder
+	script := strings.Join([]string{
+		"/join lara",
+		fmt.Sprintf(`sean: Wordle %d 3/6\n\n⬛🟨⬛⬛⬛\n🟩🟩⬛🟨⬛\n🟩🟩🟩🟩🟩`, today),
+		"nonsense",
+		"/advance 8h",
+		"/quit",
+		"sean: never read",
+	}, "\n")
+	require.NoError(t, NewREPL(db, &out, morning).Run(strings.NewReader(script)))
+
+	printed := out.String()
+	assert.Contains(t, printed, fmt.Sprintf("WordleTurtle [2]: Current Results for Wordle #%d:\n3/6: sean", today))
+	assert.Contains(t, printed, "Error: messages look like")
+	assert.Contains(t, printed, fmt.Sprintf(":hourglass: 1 hour to deadline for Wordle #%d!", today))
+	assert.Contains(t, printed, ":confetti_ball: Congratulations to sean!")
+	assert.Contains(t, printed, ":turkey: lara forgot to show up!")
+	assert.NotContains(t, printed, "never read")
+}
@@ -1,12 +1,43 @@
 package app
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// scope identifies a channel within a workspace. Results, schedules and
+// leaderboards are all kept separately for each scope.
+type scope struct {
+	team    string
+	channel string
+}
 
 type Result struct {
+	team    string
+	channel string
+	// game is the Name of the Game this result was shared for
+	game string
+	// wordlenum is the puzzle number (named for the first game we supported)
 	wordlenum   int
 	userId      string
 	displayName string
 	score       int
 	hardmode    int
-	timestamp   time.Time
+	// grid holds one color pattern per guess, e.g. "-Y--G" (see guessColors)
+	grid      []string
+	timestamp time.Time
+	// ts identifies the Slack message the result was posted in
+	ts string
+}
+
+func (r Result) scope() scope {
+	return scope{team: r.team, channel: r.channel}
+}
+
+// greens counts the correct letters in the given guess (1-indexed)
+func (r Result) greens(guess int) int {
+	if guess < 1 || guess > len(r.grid) {
+		return 0
+	}
+	return strings.Count(r.grid[guess-1], string(colorCorrect))
 }
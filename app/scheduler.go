@@ -0,0 +1,267 @@
+package app
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// jobReminder warns the channel ahead of the deadline. Each reminder's
+	// kind also holds its lead, e.g. "reminder:60" (see reminderKind).
+	jobReminder = "reminder"
+	// jobDeadline posts the final results for the day
+	jobDeadline = "deadline"
+
+	// jobLease is how long a tick has to run a job it claimed before another
+	// may assume it died and try again
+	jobLease = 10 * time.Minute
+	// maxJobAttempts is how many times a job is tried before we give up on it
+	maxJobAttempts = 5
+)
+
+// reminderKind is the kind of the job reminding the channel lead before the
+// deadline
+func reminderKind(lead time.Duration) string {
+	return fmt.Sprintf("%s:%d", jobReminder, int(lead.Minutes()))
+}
+
+// reminderLead reads the lead back out of a reminder's kind. Reminders from
+// before leads were configurable are an hour.
+func reminderLead(kind string) (time.Duration, bool) {
+	if kind == jobReminder {
+		return time.Hour, true
+	}
+	minutes, ok := strings.CutPrefix(kind, jobReminder+":")
+	if !ok {
+		return 0, false
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(m) * time.Minute, true
+}
+
+// Job is a post scheduled for later. Jobs are saved in the DB so they
+// survive restarts and Lambda freezes, and are run by runDueJobs.
+type Job struct {
+	id        int64
+	kind      string
+	team      string
+	channel   string
+	game      string
+	wordlenum int
+	runAt     time.Time
+	// attempts is how many times it's been claimed before
+	attempts int
+}
+
+func (j Job) scope() scope {
+	return scope{team: j.team, channel: j.channel}
+}
+
+// scheduleEndOfDay saves the reminder and final results posts for a puzzle,
+// at the times in the channel's settings. Saving a job that already exists
+// is a no-op, so this is safe to call for every result that comes in.
+func (h *HTTPHandler) scheduleEndOfDay(exemplar Result) error {
+	game := gameFor(exemplar)
+	settings, err := h.db.getChannelSettings(exemplar.scope())
+	if err != nil {
+		return err
+	}
+	deadline := settings.deadlineFor(game, exemplar.wordlenum)
+	base := time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, deadline.Location())
+
+	if h.now().Sub(base).Hours() > 24 {
+		log.Printf("Not scheduling old wordle: %v", exemplar)
+		return nil
+	}
+
+	runAts := map[string]time.Time{jobDeadline: deadline}
+	for _, lead := range settings.reminders {
+		runAts[reminderKind(lead)] = deadline.Add(-lead)
+	}
+	for kind, runAt := range runAts {
+		job := Job{
+			kind:      kind,
+			team:      exemplar.team,
+			channel:   exemplar.channel,
+			game:      exemplar.game,
+			wordlenum: exemplar.wordlenum,
+			runAt:     runAt,
+		}
+		if err := h.db.putJob(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDueJobs runs every job whose time has come. Each job is claimed before
+// it runs, so overlapping ticks don't both run it, and only marked done once
+// it succeeds. A job that fails is released for the next tick to retry, up
+// to maxJobAttempts, and one whose worker crashed is retried once its claim
+// lapses. A crash after posting but before finishing means a post can repeat,
+// but never goes missing.
+func (h *HTTPHandler) runDueJobs(now time.Time) error {
+	jobs, err := h.db.getDueJobs(now)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		claimed, err := h.db.claimJob(job.id, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if err := h.runJob(job, now); err != nil {
+			if job.attempts+1 < maxJobAttempts {
+				log.Printf("Job %d (%s) failed, will retry: %v", job.id, job.kind, err)
+				if err := h.db.releaseJob(job.id); err != nil {
+					return err
+				}
+				continue
+			}
+			log.Printf("Giving up on job %d (%s) after %d attempts: %v", job.id, job.kind, job.attempts+1, err)
+		}
+		if err := h.db.finishJob(job.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HTTPHandler) runJob(job Job, now time.Time) error {
+	game := gameByName(job.game)
+	if game == nil {
+		return fmt.Errorf("unknown game %q", job.game)
+	}
+	if lead, ok := reminderLead(job.kind); ok {
+		// Don't nag about a deadline that already passed while we were down
+		if now.Sub(job.runAt) > lead {
+			log.Printf("Skipping stale reminder for %s", game.PuzzleTitle(job.wordlenum))
+			return nil
+		}
+		return h.chat.PostMessage(job.channel, fmt.Sprintf(":hourglass: %s to deadline for %s! :hourglass:", formatLead(lead), game.PuzzleTitle(job.wordlenum)))
+	}
+	if job.kind == jobDeadline {
+		return h.postEndOfDay(job.scope(), game, job.wordlenum)
+	}
+	return fmt.Errorf("unknown job kind %q", job.kind)
+}
+
+func (h *HTTPHandler) postEndOfDay(sc scope, game Game, wordlenum int) error {
+	dailies, err := h.db.getDailyResults(sc, game.Name(), wordlenum)
+	if err != nil {
+		return err
+	}
+	users, err := h.chat.GetUsers(sc.channel)
+	if err != nil {
+		return err
+	}
+	players, err := h.db.getParticipation(sc)
+	if err != nil {
+		return err
+	}
+	users = expectedPlayers(users, players, game.DayForPuzzle(wordlenum))
+	missing := getMissingPlayers(h.chat, users, dailies)
+
+	notes := []string{}
+	if len(missing) > 0 {
+		notes = append(notes, fmt.Sprintf(":turkey: %s forgot to show up!", namesString(missing)))
+	}
+
+	milestones, err := getStreakMilestones(h.db, sc, game, wordlenum, dailies)
+	if err != nil {
+		return err
+	}
+	notes = append(notes, milestones...)
+
+	ratingChanges, err := updateRatings(h.db, sc, game, wordlenum, dailies)
+	if err != nil {
+		return err
+	}
+	if ratingChanges != "" {
+		notes = append(notes, ratingChanges)
+	}
+
+	if _, err := h.chat.PostRichMessage(sc.channel, finalSummaryPost(dailies, notes)); err != nil {
+		return err
+	}
+
+	// Post the weekly leaderboard on Saturday, and the monthly and yearly
+	// ones when they end
+	settings, err := h.db.getChannelSettings(sc)
+	if err != nil {
+		return err
+	}
+	periods := []leaderboardPeriod{}
+	if settings.dayFor(game, wordlenum).Weekday() == time.Saturday {
+		periods = append(periods, weekPeriod(settings, game, wordlenum))
+	}
+	next := settings.dayFor(game, wordlenum+1)
+	if next.Day() == 1 {
+		periods = append(periods, monthPeriod(settings, game, wordlenum))
+	}
+	if next.YearDay() == 1 {
+		periods = append(periods, yearPeriod(settings, game, wordlenum))
+	}
+	for _, period := range periods {
+		leaderboard, err := getLeaderBoardPost(h.db, h.chat, sc, game, period)
+		if err != nil {
+			return err
+		}
+		if _, err := h.chat.PostRichMessage(sc.channel, leaderboard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleTick runs any due jobs, and any events that were somehow left in
+// the queue. In production something external (e.g. an EventBridge
+// schedule) calls it every minute, since a Lambda can't keep a timer running
+// between requests.
+func (h *HTTPHandler) handleTick(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizedTick(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err := h.runDueJobs(h.now()); err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if err := h.runQueuedEvents(); err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// authorizedTick checks a request carries the tick secret
+func (h *HTTPHandler) authorizedTick(r *http.Request) bool {
+	expected := "Bearer " + h.config.TickSecret
+	return h.config.TickSecret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) == 1
+}
+
+// runTicker stands in for the external tick when running as a plain server
+func (h *HTTPHandler) runTicker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := h.runDueJobs(h.now()); err != nil {
+			log.Print(err)
+		}
+		if err := h.runQueuedEvents(); err != nil {
+			log.Print(err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"wordleturtle/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_scheduleEndOfDay(t *testing.T) {
+	loc := DefaultLocation()
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	londonSettings := ChannelSettings{location: london, deadline: 18*60 + 30, reminders: []time.Duration{time.Hour, 15 * time.Minute}}
+
+	tests := []struct {
+		name     string
+		day      time.Time
+		settings ChannelSettings
+		// deadline is the wall clock time of the deadline on day
+		deadline time.Time
+	}{
+		{"ordinary day", time.Date(2024, 12, 23, 0, 0, 0, 0, loc), defaultChannelSettings(), time.Date(2024, 12, 23, 17, 0, 0, 0, loc)},
+		// The clocks change at 2AM, so the deadline isn't 17 hours after midnight
+		{"DST starts", time.Date(2025, 3, 9, 0, 0, 0, 0, loc), defaultChannelSettings(), time.Date(2025, 3, 9, 17, 0, 0, 0, loc)},
+		{"DST ends", time.Date(2025, 11, 2, 0, 0, 0, 0, loc), defaultChannelSettings(), time.Date(2025, 11, 2, 17, 0, 0, 0, loc)},
+		// The UK changes its clocks three weeks after the US
+		{"London", time.Date(2025, 3, 30, 0, 0, 0, 0, london), londonSettings, time.Date(2025, 3, 30, 18, 30, 0, 0, london)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := new(MockDB)
+			h := &HTTPHandler{db: mockDb, clock: newFakeClock(tt.day.Add(9 * time.Hour))}
+
+			today := tt.settings.puzzleToday(wordle{}, tt.day.Add(9*time.Hour))
+			mockDb.On("getChannelSettings", testScope).Return(tt.settings, nil)
+			mockDb.On("putJob", Job{kind: jobDeadline, team: "testteam", channel: "testchannel", game: "Wordle", wordlenum: today, runAt: tt.deadline}).Return(nil)
+			for _, lead := range tt.settings.reminders {
+				mockDb.On("putJob", Job{kind: reminderKind(lead), team: "testteam", channel: "testchannel", game: "Wordle", wordlenum: today, runAt: tt.deadline.Add(-lead)}).Return(nil)
+			}
+
+			res := makeResult("userid1", "sean", today, 3)
+			res.team, res.channel = "testteam", "testchannel"
+			assert.Nil(t, h.scheduleEndOfDay(res))
+			mockDb.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_scheduleEndOfDay_Old(t *testing.T) {
+	mockDb := new(MockDB)
+	h := &HTTPHandler{db: mockDb}
+	mockDb.On("getChannelSettings", mock.Anything).Return(defaultChannelSettings(), nil)
+
+	assert.Nil(t, h.scheduleEndOfDay(makeResult("userid1", "sean", 917, 3)))
+	mockDb.AssertNotCalled(t, "putJob", mock.Anything)
+}
+
+func Test_runDueJobs_Retries(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	now := DayForWordle(1283).Add(16 * time.Hour)
+	reminder := Job{id: 1, kind: reminderKind(time.Hour), team: "testteam", channel: "testchannel", game: "Wordle", wordlenum: 1283, runAt: now}
+	mockDb.On("getDueJobs", now).Return([]Job{reminder}, nil).Once()
+	mockDb.On("claimJob", int64(1), now).Return(true, nil)
+	mockSlack.On("PostMessage", "testchannel", mock.Anything).Return(errors.New("slack is down"))
+	// Left for the next tick
+	mockDb.On("releaseJob", int64(1)).Return(nil).Once()
+	assert.Nil(t, h.runDueJobs(now))
+
+	// Until it's failed too often
+	reminder.attempts = maxJobAttempts - 1
+	mockDb.On("getDueJobs", now).Return([]Job{reminder}, nil).Once()
+	mockDb.On("finishJob", int64(1)).Return(nil).Once()
+	assert.Nil(t, h.runDueJobs(now))
+	mockDb.AssertExpectations(t)
+}
+
+func Test_runDueJobs(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	// Saturday, so the weekly leaderboard is posted too
+	wordlenum := 1288
+	now := DayForWordle(wordlenum).Add(17 * time.Hour)
+	jobs := []Job{
+		{id: 1, kind: jobReminder, team: "testteam", channel: "testchannel", game: "Wordle", wordlenum: wordlenum, runAt: now.Add(-time.Hour)},
+		{id: 2, kind: jobDeadline, team: "testteam", channel: "testchannel", game: "Wordle", wordlenum: wordlenum, runAt: now},
+	}
+	mockDb.On("getDueJobs", now).Return(jobs, nil)
+	// Another tick got to the reminder first
+	mockDb.On("claimJob", int64(1), now).Return(false, nil)
+	mockDb.On("claimJob", int64(2), now).Return(true, nil)
+	mockDb.On("finishJob", int64(2)).Return(nil).Once()
+	mockDb.On("getDailyResults", testScope, "Wordle", mock.Anything).Return([]Result{makeResult("userid1", "sean", wordlenum, 3)}, nil)
+	// sean has played every day this week
+	history := make([]Result, 0)
+	for i := wordlenum - 6; i <= wordlenum; i++ {
+		history = append(history, makeResult("userid1", "sean", i, 3))
+	}
+	mockDb.On("getPlayerResults", testScope, "Wordle", "userid1").Return(history, nil)
+	mockDb.On("getResultsInRange", testScope, "Wordle", wordlenum-6, wordlenum).Return(history, nil)
+	mockDb.On("getRatingsBefore", testScope, "Wordle", mock.Anything).Return([]Rating{}, nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("getRatings", testScope, "Wordle").Return([]Rating{}, nil)
+	mockDb.On("putRating", testScope, "Wordle", mock.Anything).Return(nil)
+
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	finalMatcher := mock.MatchedBy(func(msg RichMessage) bool {
+		matches, err := regexp.Match(`^:confetti_ball: Congratulations to sean!(?s).*sean has played 7 days in a row!`, []byte(msg.text))
+		return matches && err == nil
+	})
+	leaderboardMatcher := mock.MatchedBy(func(msg RichMessage) bool {
+		matches, err := regexp.Match(`^Weekly Wordle Leaderboard`, []byte(msg.text))
+		return matches && err == nil
+	})
+	mockSlack.On("PostRichMessage", "testchannel", finalMatcher).Return("", nil).Once()
+	mockSlack.On("PostRichMessage", "testchannel", leaderboardMatcher).Return("", nil).Once()
+
+	assert.Nil(t, h.runDueJobs(now))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_postEndOfDay_NoUsers(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	// Without the channel's members nobody would be called out as missing,
+	// so the job fails to be retried
+	mockDb.On("getDailyResults", testScope, "Wordle", 1283).Return([]Result{}, nil)
+	mockSlack.On("GetUsers", "testchannel").Return([]string(nil), errors.New("slack is down"))
+	assert.Error(t, h.postEndOfDay(testScope, wordle{}, 1283))
+	mockSlack.AssertNotCalled(t, "PostRichMessage", mock.Anything, mock.Anything)
+}
+
+func Test_handleTick_Unauthorized(t *testing.T) {
+	h := &HTTPHandler{config: &config.BotConfig{TickSecret: "secret"}}
+
+	req := httptest.NewRequest(http.MethodPost, "/tick", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+	h.handleTick(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func Test_postEndOfDay_YearEnd(t *testing.T) {
+	mockDb := new(MockDB)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{db: mockDb, chat: mockSlack}
+
+	// New Year's Eve 2024 was a Tuesday, so the monthly and yearly boards are
+	// posted but not the weekly one
+	wordlenum := 1291
+	mockDb.On("getDailyResults", testScope, "Wordle", wordlenum).Return([]Result{makeResult("userid1", "sean", wordlenum, 3)}, nil)
+	mockDb.On("getPlayerResults", testScope, "Wordle", "userid1").Return([]Result{makeResult("userid1", "sean", wordlenum, 3)}, nil)
+	mockDb.On("getResultsInRange", testScope, "Wordle", 1261, wordlenum).Return([]Result{}, nil)
+	mockDb.On("getResultsInRange", testScope, "Wordle", 926, wordlenum).Return([]Result{}, nil)
+	mockDb.On("getRatingsBefore", testScope, "Wordle", mock.Anything).Return([]Rating{}, nil)
+	mockDb.On("getChannelSettings", testScope).Return(defaultChannelSettings(), nil)
+	mockDb.On("getRatings", testScope, "Wordle").Return([]Rating{}, nil)
+	mockDb.On("putRating", testScope, "Wordle", mock.Anything).Return(nil)
+
+	mockSlack.On("GetUsers", "testchannel").Return([]string{"userid1"}, nil)
+	mockDb.On("getParticipation", testScope).Return(map[string]Participation{}, nil)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	headingMatcher := func(heading string) interface{} {
+		return mock.MatchedBy(func(msg RichMessage) bool {
+			return strings.HasPrefix(msg.text, heading)
+		})
+	}
+	mockSlack.On("PostRichMessage", "testchannel", headingMatcher(":confetti_ball:")).Return("", nil).Once()
+	mockSlack.On("PostRichMessage", "testchannel", headingMatcher("December 2024 Wordle Leaderboard")).Return("", nil).Once()
+	mockSlack.On("PostRichMessage", "testchannel", headingMatcher("2024 Wordle Leaderboard")).Return("", nil).Once()
+
+	assert.Nil(t, h.postEndOfDay(testScope, wordle{}, wordlenum))
+	mockSlack.AssertExpectations(t)
+}
+
+func Test_handleTick_Saturday(t *testing.T) {
+	tests := []struct {
+		name        string
+		wordlenum   int
+		leaderboard bool
+	}{
+		{"Friday", 1287, false},
+		{"Saturday", 1288, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+			require.NoError(t, err)
+			var out strings.Builder
+			clock := newFakeClock(DayForWordle(tt.wordlenum).Add(9 * time.Hour))
+			h := &HTTPHandler{
+				config: &config.BotConfig{TickSecret: "secret"},
+				db:     db,
+				chat:   &replConnection{out: &out, users: []string{"sean", "lara"}},
+				clock:  clock,
+			}
+			require.NoError(t, h.handleUserMessage(ChatMessage{team: "testteam", channel: "testchannel", user: "sean", text: fmt.Sprintf("Wordle %d 3/6", tt.wordlenum), ts: "1"}))
+
+			tick := func() {
+				req := httptest.NewRequest(http.MethodPost, "/tick", nil)
+				req.Header.Set("Authorization", "Bearer secret")
+				w := httptest.NewRecorder()
+				h.handleTick(w, req)
+				assert.Equal(t, http.StatusOK, w.Code)
+			}
+			clock.Advance(7*time.Hour + 59*time.Minute)
+			tick()
+			assert.NotContains(t, out.String(), "Final Results")
+
+			clock.Advance(time.Minute)
+			tick()
+			assert.Contains(t, out.String(), "Final Results for Wordle #"+fmt.Sprint(tt.wordlenum))
+			assert.Equal(t, tt.leaderboard, strings.Contains(out.String(), "Weekly Wordle Leaderboard"))
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelSettings are when a channel's day ends and when it's reminded
+type ChannelSettings struct {
+	location *time.Location
+	// deadline is when the day's results are final, in minutes after midnight
+	deadline int
+	// reminders are how long before the deadline to remind the channel
+	reminders []time.Duration
+	// grace is how far either side of the channel's day a puzzle still
+	// counts, for people posting late or from further east
+	grace time.Duration
+}
+
+// defaultChannelSettings are 5PM Pacific with a reminder an hour before,
+// and three hours' grace
+func defaultChannelSettings() ChannelSettings {
+	return ChannelSettings{location: DefaultLocation(), deadline: 17 * 60, reminders: []time.Duration{time.Hour}, grace: 3 * time.Hour}
+}
+
+// dayFor returns the start of the channel's day that a puzzle is played on
+func (s ChannelSettings) dayFor(game Game, num int) time.Time {
+	day := game.DayForPuzzle(num)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, s.location)
+}
+
+// deadlineFor returns when a puzzle's results are final in the channel
+func (s ChannelSettings) deadlineFor(game Game, num int) time.Time {
+	day := s.dayFor(game, num)
+	return time.Date(day.Year(), day.Month(), day.Day(), s.deadline/60, s.deadline%60, 0, 0, s.location)
+}
+
+// puzzleToday returns the puzzle being played in the channel at now.
+// Puzzles follow the calendar, so London moves on eight hours before
+// Los Angeles does.
+func (s ChannelSettings) puzzleToday(game Game, now time.Time) int {
+	local := now.In(s.location)
+	return game.PuzzleForDay(time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, DefaultLocation()))
+}
+
+// checkPuzzle returns why a result for puzzle num can't count at now, if it
+// can't
+func (s ChannelSettings) checkPuzzle(game Game, num int, now time.Time) error {
+	today := s.puzzleToday(game, now)
+	if num < s.puzzleToday(game, now.Add(-s.grace)) {
+		return fmt.Errorf("%s was %s, too long ago to count. Today's is %s", game.PuzzleTitle(num), game.DayForPuzzle(num).Format("Mon Jan 2"), game.PuzzleTitle(today))
+	}
+	if num > s.puzzleToday(game, now.Add(s.grace)) {
+		return fmt.Errorf("%s isn't out yet here, so I can't count it. Today's is %s", game.PuzzleTitle(num), game.PuzzleTitle(today))
+	}
+	return nil
+}
+
+func (s ChannelSettings) String() string {
+	reminders := "none"
+	if len(s.reminders) > 0 {
+		leads := make([]string, 0, len(s.reminders))
+		for _, lead := range s.reminders {
+			leads = append(leads, formatLead(lead))
+		}
+		reminders = strings.Join(leads, ", ") + " before"
+	}
+	grace := "none"
+	if s.grace > 0 {
+		grace = formatLead(s.grace)
+	}
+	return fmt.Sprintf("Timezone: %s\nDeadline: %02d:%02d\nReminders: %s\nGrace: %s", s.location, s.deadline/60, s.deadline%60, reminders, grace)
+}
+
+// formatLead describes a reminder's lead, e.g. "1 hour" or "1 hour 30 minutes"
+func formatLead(lead time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	hours, minutes := int(lead.Hours()), int(lead.Minutes())%60
+	switch {
+	case hours == 0:
+		return plural(minutes, "minute")
+	case minutes == 0:
+		return plural(hours, "hour")
+	}
+	return plural(hours, "hour") + " " + plural(minutes, "minute")
+}
+
+// parseDeadline reads a time of day like "17:00" into minutes after midnight
+func parseDeadline(arg string) (int, error) {
+	t, err := time.Parse("15:04", arg)
+	if err != nil {
+		return 0, fmt.Errorf("I don't understand the time %q. Try something like 17:00", arg)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseReminders reads reminder leads like "1h 15m", or "none"
+func parseReminders(args []string) ([]time.Duration, error) {
+	reminders := []time.Duration{}
+	if len(args) == 1 && args[0] == "none" {
+		return reminders, nil
+	}
+	for _, arg := range args {
+		lead, err := time.ParseDuration(arg)
+		if err != nil || lead < time.Minute || lead >= 24*time.Hour {
+			return nil, fmt.Errorf("I don't understand the reminder %q. Try something like 1h or 15m, or none", arg)
+		}
+		reminders = append(reminders, lead.Truncate(time.Minute))
+	}
+	if len(reminders) == 0 {
+		return nil, fmt.Errorf("Give me the reminders, like 1h 15m, or none")
+	}
+	return reminders, nil
+}
+
+// encodeReminders stores reminders as comma separated minutes
+func encodeReminders(reminders []time.Duration) string {
+	minutes := make([]string, 0, len(reminders))
+	for _, lead := range reminders {
+		minutes = append(minutes, strconv.Itoa(int(lead.Minutes())))
+	}
+	return strings.Join(minutes, ",")
+}
+
+func decodeReminders(encoded string) ([]time.Duration, error) {
+	reminders := []time.Duration{}
+	if encoded == "" {
+		return reminders, nil
+	}
+	for _, m := range strings.Split(encoded, ",") {
+		minutes, err := strconv.Atoi(m)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, time.Duration(minutes)*time.Minute)
+	}
+	return reminders, nil
+}
+
+// handleSettings shows the channel's settings, or lets an admin change one:
+// settings [timezone <zone> | deadline <HH:MM> | reminders <lead>... | reminders none | grace <duration>]
+func (h *HTTPHandler) handleSettings(sm ChatMessage, args commandArgs) error {
+	settings, err := h.db.getChannelSettings(sm.scope())
+	if err != nil {
+		return err
+	}
+	setting, values := args.get("setting"), args["value"]
+	if setting == "" {
+		return h.chat.PostMessage(sm.channel, "Settings for this channel:\n"+settings.String())
+	}
+
+	admin, err := h.isAdmin(sm.scope(), sm.user)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return h.chat.PostMessage(sm.channel, "Only admins can change settings")
+	}
+
+	switch {
+	case setting == "timezone" && len(values) == 1:
+		loc, err := time.LoadLocation(values[0])
+		if err != nil {
+			return h.chat.PostMessage(sm.channel, fmt.Sprintf("I don't know the timezone %q. Try something like Europe/London", values[0]))
+		}
+		settings.location = loc
+	case setting == "deadline" && len(values) == 1:
+		if settings.deadline, err = parseDeadline(values[0]); err != nil {
+			return h.chat.PostMessage(sm.channel, err.Error())
+		}
+	case setting == "reminders":
+		if settings.reminders, err = parseReminders(values); err != nil {
+			return h.chat.PostMessage(sm.channel, err.Error())
+		}
+	case setting == "grace" && len(values) == 1:
+		grace, err := time.ParseDuration(values[0])
+		if err != nil || grace < 0 || grace > 24*time.Hour {
+			return h.chat.PostMessage(sm.channel, fmt.Sprintf("I don't understand the grace %q. Try something like 3h, or 0", values[0]))
+		}
+		settings.grace = grace.Truncate(time.Minute)
+	default:
+		return h.chat.PostMessage(sm.channel, "Try settings timezone <zone>, settings deadline <HH:MM>, settings reminders <lead>... or settings grace <duration>")
+	}
+
+	if err := h.db.putChannelSettings(sm.scope(), settings); err != nil {
+		return err
+	}
+	if err := h.reschedule(sm.scope()); err != nil {
+		return err
+	}
+	return h.chat.PostMessage(sm.channel, "Settings for this channel are now:\n"+settings.String())
+}
+
+// reschedule moves the channel's pending posts to match its settings
+func (h *HTTPHandler) reschedule(sc scope) error {
+	jobs, err := h.db.getPendingJobs(sc)
+	if err != nil {
+		return err
+	}
+	type puzzle struct {
+		game      string
+		wordlenum int
+	}
+	puzzles := map[puzzle]bool{}
+	for _, job := range jobs {
+		unscheduled, err := h.db.unscheduleJob(job.id)
+		if err != nil {
+			return err
+		}
+		if unscheduled {
+			puzzles[puzzle{game: job.game, wordlenum: job.wordlenum}] = true
+		}
+	}
+	for p := range puzzles {
+		exemplar := Result{team: sc.team, channel: sc.channel, game: p.game, wordlenum: p.wordlenum}
+		if err := h.scheduleEndOfDay(exemplar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
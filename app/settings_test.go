@@ -0,0 +1,115 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_puzzleToday(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	settings := defaultChannelSettings()
+	settings.location = london
+
+	// Still the 23rd in Los Angeles
+	now := time.Date(2024, 12, 24, 0, 30, 0, 0, london)
+	assert.Equal(t, 1284, settings.puzzleToday(wordle{}, now))
+	assert.Equal(t, 1283, defaultChannelSettings().puzzleToday(wordle{}, now))
+}
+
+func Test_checkPuzzle(t *testing.T) {
+	settings := defaultChannelSettings()
+	// 1283 is Dec 23
+	morning := time.Date(2024, 12, 23, 9, 0, 0, 0, DefaultLocation())
+	lateNight := time.Date(2024, 12, 23, 22, 0, 0, 0, DefaultLocation())
+	tests := []struct {
+		name      string
+		now       time.Time
+		wordlenum int
+		ok        bool
+	}{
+		{"today", morning, 1283, true},
+		{"yesterday", morning, 1282, false},
+		{"tomorrow", morning, 1284, false},
+		{"yesterday just after midnight", time.Date(2024, 12, 23, 1, 0, 0, 0, DefaultLocation()), 1282, true},
+		{"tomorrow, from further east", lateNight, 1284, true},
+		{"far future", morning, 9999, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := settings.checkPuzzle(wordle{}, tt.wordlenum, tt.now)
+			assert.Equal(t, tt.ok, err == nil, err)
+		})
+	}
+
+	err := settings.checkPuzzle(wordle{}, 9999, morning)
+	assert.EqualError(t, err, "Wordle #9999 isn't out yet here, so I can't count it. Today's is Wordle #1283")
+	err = settings.checkPuzzle(wordle{}, 1200, morning)
+	assert.EqualError(t, err, "Wordle #1200 was Tue Oct 1, too long ago to count. Today's is Wordle #1283")
+}
+
+func Test_parseReminders(t *testing.T) {
+	reminders, err := parseReminders([]string{"1h", "15m"})
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Hour, 15 * time.Minute}, reminders)
+
+	reminders, err = parseReminders([]string{"none"})
+	require.NoError(t, err)
+	assert.Empty(t, reminders)
+
+	_, err = parseReminders([]string{"25h"})
+	assert.Error(t, err)
+}
+
+func Test_formatLead(t *testing.T) {
+	assert.Equal(t, "1 hour", formatLead(time.Hour))
+	assert.Equal(t, "15 minutes", formatLead(15*time.Minute))
+	assert.Equal(t, "2 hours 1 minute", formatLead(121*time.Minute))
+}
+
+func Test_handleSettings(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	morning := DayForWordle(1283).Add(9 * time.Hour)
+	h := &HTTPHandler{db: db, chat: mockSlack, clock: newFakeClock(morning)}
+
+	exemplar := makeResult("userid1", "sean", 1283, 3)
+	exemplar.team, exemplar.channel = "testteam", "testchannel"
+	require.NoError(t, h.scheduleEndOfDay(exemplar))
+	settings := func(user, args string) error {
+		return h.handleCommand(ChatMessage{team: "testteam", channel: "testchannel", user: user}, "settings", strings.Fields(args))
+	}
+
+	mockSlack.On("IsAdmin", "testchannel", "userid2").Return(false, nil)
+	mockSlack.On("PostMessage", "testchannel", "Only admins can change settings").Return(nil).Once()
+	require.NoError(t, settings("userid2", "deadline 12:00"))
+
+	mockSlack.On("IsAdmin", "testchannel", "userid1").Return(true, nil)
+	mockSlack.On("PostMessage", "testchannel", "Settings for this channel are now:\nTimezone: Europe/London\nDeadline: 17:00\nReminders: 1 hour before\nGrace: 3 hours").Return(nil).Once()
+	require.NoError(t, settings("userid1", "timezone Europe/London"))
+	mockSlack.On("PostMessage", "testchannel", "Settings for this channel are now:\nTimezone: Europe/London\nDeadline: 17:00\nReminders: 2 hours, 30 minutes before\nGrace: 3 hours").Return(nil).Once()
+	require.NoError(t, settings("userid1", "reminders 2h 30m"))
+	mockSlack.On("PostMessage", "testchannel", "Settings for this channel:\nTimezone: Europe/London\nDeadline: 17:00\nReminders: 2 hours, 30 minutes before\nGrace: 3 hours").Return(nil).Once()
+	require.NoError(t, settings("userid2", ""))
+	mockSlack.AssertExpectations(t)
+
+	// Today's posts moved to London time
+	london, _ := time.LoadLocation("Europe/London")
+	deadline := time.Date(2024, 12, 23, 17, 0, 0, 0, london)
+	jobs, err := db.getPendingJobs(testScope)
+	require.NoError(t, err)
+	runAts := map[string]time.Time{}
+	for _, job := range jobs {
+		runAts[job.kind] = job.runAt
+	}
+	assert.Len(t, runAts, 3)
+	assert.True(t, runAts[jobDeadline].Equal(deadline))
+	assert.True(t, runAts["reminder:120"].Equal(deadline.Add(-2*time.Hour)))
+	assert.True(t, runAts["reminder:30"].Equal(deadline.Add(-30*time.Minute)))
+}
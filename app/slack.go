@@ -1,28 +1,43 @@
 package app
 
 import (
+	"log"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/slack-go/slack"
 )
 
-type SlackConnection interface {
-	NameForUser(userId string) (string, error)
-	PostMessage(channel, msg string) error
-	GetUsers(channel string) ([]string, error)
-}
-
+// SlackAPIConnection is a ChatConnection to Slack's Web API
 type SlackAPIConnection struct {
 	api       *slack.Client
 	nameCache sync.Map
+	// botUserID is looked up the first time it's needed
+	botUserID   string
+	botUserIDMu sync.Mutex
+	// members caches each channel's people for membersTTL
+	members    membersCache
+	membersTTL time.Duration
+	// people caches whether users are people, as knownUsers
+	people sync.Map
+	// clock is the real one when nil
+	clock Clock
+}
+
+// knownUser records whether a user is a person, until it expires
+type knownUser struct {
+	person  bool
+	expires time.Time
 }
 
-func NewSlackAPIConnection(slackToken string) *SlackAPIConnection {
+func NewSlackAPIConnection(slackToken string, membersTTL time.Duration) *SlackAPIConnection {
 	api := slack.New(slackToken)
 	if api == nil {
 		panic("Failed to connect to slack")
 	}
-	return &SlackAPIConnection{api: api}
+	return &SlackAPIConnection{api: api, membersTTL: membersTTL}
 
 }
 
@@ -34,15 +49,20 @@ func (s *SlackAPIConnection) NameForUser(userId string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return s.cacheName(user), nil
+}
+
+// cacheName remembers the name we call a user by, and returns it
+func (s *SlackAPIConnection) cacheName(user *slack.User) string {
 	// too many names
 	names := []string{user.Profile.DisplayName, user.Profile.DisplayNameNormalized, user.Profile.FirstName, user.Profile.RealName, user.Profile.RealNameNormalized}
 	for _, n := range names {
 		if n != "" {
-			s.nameCache.Store(userId, n)
-			return n, nil
+			s.nameCache.Store(user.ID, n)
+			return n
 		}
 	}
-	return userId, nil
+	return user.ID
 }
 
 func (s *SlackAPIConnection) PostMessage(channel, msg string) error {
@@ -50,8 +70,116 @@ func (s *SlackAPIConnection) PostMessage(channel, msg string) error {
 	return err
 }
 
+func (s *SlackAPIConnection) PostRichMessage(channel string, msg RichMessage) (string, error) {
+	_, ts, err := s.api.PostMessage(channel, slack.MsgOptionText(msg.text, false), slack.MsgOptionBlocks(msg.blocks...))
+	return ts, err
+}
+
+func (s *SlackAPIConnection) UpdateRichMessage(channel, ts string, msg RichMessage) error {
+	_, _, _, err := s.api.UpdateMessage(channel, ts, slack.MsgOptionText(msg.text, false), slack.MsgOptionBlocks(msg.blocks...))
+	return err
+}
+
+func (s *SlackAPIConnection) PostThreadReply(channel, threadTs, msg string) error {
+	_, _, err := s.api.PostMessage(channel, slack.MsgOptionText(msg, false), slack.MsgOptionTS(threadTs))
+	return err
+}
+
+func (s *SlackAPIConnection) BotUserID() (string, error) {
+	s.botUserIDMu.Lock()
+	defer s.botUserIDMu.Unlock()
+	if s.botUserID == "" {
+		auth, err := s.api.AuthTest()
+		if err != nil {
+			return "", err
+		}
+		s.botUserID = auth.UserID
+	}
+	return s.botUserID, nil
+}
+
+// IsAdmin is true for the workspace's admins and owners
+func (s *SlackAPIConnection) IsAdmin(channel, userId string) (bool, error) {
+	user, err := s.api.GetUserInfo(userId)
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin || user.IsOwner, nil
+}
+
+// GetUsers returns the people in a channel, leaving out bots and deactivated
+// accounts. They're cached until membersTTL passes or someone joins or
+// leaves.
 func (s *SlackAPIConnection) GetUsers(channel string) ([]string, error) {
-	params := slack.GetUsersInConversationParameters{ChannelID: channel, Limit: 100}
-	users, _, err := s.api.GetUsersInConversation(&params)
-	return users, err
+	return s.members.get(channel, s.now, s.membersTTL, s.fetchMembers)
+}
+
+// fetchMembers pages through a channel's members, leaving out bots and
+// deactivated accounts. Anyone who can't be looked up is left out too,
+// rather than failing the whole list.
+func (s *SlackAPIConnection) fetchMembers(channel string) ([]string, error) {
+	params := slack.GetUsersInConversationParameters{ChannelID: channel, Limit: 200}
+	userIds := make([]string, 0)
+	for {
+		page, cursor, err := s.api.GetUsersInConversation(&params)
+		if err != nil {
+			return nil, err
+		}
+		for _, userId := range page {
+			person, err := s.isPerson(userId)
+			if err != nil {
+				log.Printf("Leaving %s out of %s's members: %v", userId, channel, err)
+				continue
+			}
+			if person {
+				userIds = append(userIds, userId)
+			}
+		}
+		if cursor == "" {
+			return userIds, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
+// isPerson reports whether a user is a person rather than a bot or a
+// deactivated account. Users are looked up at most once per membersTTL.
+func (s *SlackAPIConnection) isPerson(userId string) (bool, error) {
+	now := s.now()
+	if known, ok := s.people.Load(userId); ok && now.Before(known.(knownUser).expires) {
+		return known.(knownUser).person, nil
+	}
+	user, err := s.api.GetUserInfo(userId)
+	if err != nil {
+		return false, err
+	}
+	// Saves looking them up again for the summary
+	s.cacheName(user)
+	person := !user.IsBot && !user.Deleted && user.ID != "USLACKBOT"
+	s.people.Store(userId, knownUser{person: person, expires: now.Add(s.membersTTL)})
+	return person, nil
+}
+
+// forgetMembers drops a channel's cached members, after someone joins or
+// leaves it
+func (s *SlackAPIConnection) forgetMembers(channel string) {
+	s.members.forget(channel)
+}
+
+func (s *SlackAPIConnection) now() time.Time {
+	if s.clock == nil {
+		return NowDefault()
+	}
+	return s.clock.Now()
+}
+
+// slackTime is when the message with ts was posted, or zero if ts isn't a
+// message timestamp. ts is unix seconds, with a sequence number after the dot.
+func slackTime(ts string) time.Time {
+	seconds, _, _ := strings.Cut(ts, ".")
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
 }
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSlackMembers serves conversations.members in two pages, and users.info
+// for people, a bot and a deactivated account. Looking up UFAIL fails.
+func fakeSlackMembers(t *testing.T) (*httptest.Server, map[string]int) {
+	calls := map[string]int{}
+	var mu sync.Mutex
+	users := map[string]map[string]any{
+		"U1":    {"id": "U1", "profile": map[string]any{"display_name": "sean"}},
+		"U2":    {"id": "U2", "profile": map[string]any{"display_name": "lara"}},
+		"UBOT":  {"id": "UBOT", "is_bot": true, "profile": map[string]any{"display_name": "WordleTurtle"}},
+		"UGONE": {"id": "UGONE", "deleted": true, "profile": map[string]any{"display_name": "pat"}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		mu.Lock()
+		calls[r.URL.Path]++
+		mu.Unlock()
+		var resp map[string]any
+		switch r.URL.Path {
+		case "/conversations.members":
+			assert.Equal(t, "C1", r.Form.Get("channel"))
+			if r.Form.Get("cursor") == "" {
+				resp = map[string]any{"ok": true, "members": []string{"U1", "UBOT"}, "response_metadata": map[string]any{"next_cursor": "page2"}}
+			} else {
+				assert.Equal(t, "page2", r.Form.Get("cursor"))
+				resp = map[string]any{"ok": true, "members": []string{"U2", "UGONE", "UFAIL"}}
+			}
+		case "/users.info":
+			resp = map[string]any{"ok": true, "user": users[r.Form.Get("user")]}
+			if r.Form.Get("user") == "UFAIL" {
+				resp = map[string]any{"ok": false, "error": "user_not_found"}
+			}
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	return server, calls
+}
+
+func Test_SlackAPIConnection_GetUsers(t *testing.T) {
+	server, calls := fakeSlackMembers(t)
+	defer server.Close()
+	clock := newFakeClock(DayForWordle(1283).Add(9 * time.Hour))
+	s := &SlackAPIConnection{api: slack.New("xoxb-test", slack.OptionAPIURL(server.URL+"/")), membersTTL: 10 * time.Minute, clock: clock}
+	h := &HTTPHandler{chat: s}
+
+	// Everyone asking at once shares one lookup, which leaves out UFAIL
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users, err := s.GetUsers("C1")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"U1", "U2"}, users)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, calls["/conversations.members"])
+	assert.Equal(t, 5, calls["/users.info"])
+
+	// Names were looked up along the way, and the members are cached
+	name, err := s.NameForUser("U2")
+	require.NoError(t, err)
+	assert.Equal(t, "lara", name)
+	users, err := s.GetUsers("C1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"U1", "U2"}, users)
+	assert.Equal(t, 2, calls["/conversations.members"])
+	assert.Equal(t, 5, calls["/users.info"])
+
+	// Until someone joins
+	require.NoError(t, h.dispatchEvent(slackevents.EventsAPIEvent{InnerEvent: slackevents.EventsAPIInnerEvent{
+		Type: string(slackevents.MemberJoinedChannel),
+		Data: &slackevents.MemberJoinedChannelEvent{User: "U3", Channel: "C1"},
+	}}))
+	_, err = s.GetUsers("C1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls["/conversations.members"])
+	// Only the user who couldn't be looked up is tried again
+	assert.Equal(t, 6, calls["/users.info"])
+
+	// Or the cache expires
+	clock.Advance(5 * time.Minute)
+	_, err = s.GetUsers("C1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls["/conversations.members"])
+	clock.Advance(6 * time.Minute)
+	_, err = s.GetUsers("C1")
+	require.NoError(t, err)
+	assert.Equal(t, 6, calls["/conversations.members"])
+	assert.Equal(t, 11, calls["/users.info"])
+}
+
+func Test_slackTime(t *testing.T) {
+	assert.Equal(t, time.Unix(1700000000, 0), slackTime("1700000000.000100"))
+	assert.True(t, slackTime("").IsZero())
+}
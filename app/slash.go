@@ -0,0 +1,207 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// slashShare is the word that posts a slash command's reply to the
+	// channel instead of only to whoever ran it
+	slashShare = "share"
+	// slashSubmitHelp is the help line for the subcommand only /wordle has
+	slashSubmitHelp = "submit <result> - record a result from the game's share text"
+	// slashWorking is the reply to a command while it waits in the queue
+	slashWorking = ":hourglass_flowing_sand: Working on it..."
+	// slashFailed is the reply to a command that went wrong
+	slashFailed = "Sorry, something went wrong"
+	// slashEventPrefix starts the ids of slash commands in the event queue
+	slashEventPrefix = "slash:"
+)
+
+// slashReply is a ChatConnection that keeps what a command says, so it can
+// be the response to a slash command rather than a post. Everything else,
+// like replies to other messages, goes to the real connection.
+type slashReply struct {
+	ChatConnection
+	// ts stands in for the command's message, which replies in its thread
+	// are kept as the response
+	ts string
+	// summaries lets live summaries through to the channel, for submitted
+	// results
+	summaries bool
+	texts     []string
+	blocks    []slack.Block
+}
+
+func (s *slashReply) PostMessage(channel, msg string) error {
+	s.texts = append(s.texts, msg)
+	return nil
+}
+
+func (s *slashReply) PostThreadReply(channel, threadTs, msg string) error {
+	if threadTs != s.ts {
+		return s.ChatConnection.PostThreadReply(channel, threadTs, msg)
+	}
+	s.texts = append(s.texts, msg)
+	return nil
+}
+
+func (s *slashReply) PostRichMessage(channel string, msg RichMessage) (string, error) {
+	if s.summaries {
+		return s.ChatConnection.PostRichMessage(channel, msg)
+	}
+	s.texts = append(s.texts, msg.text)
+	s.blocks = append(s.blocks, msg.blocks...)
+	return "", nil
+}
+
+// message is the response to the slash command. Only whoever ran it sees
+// it, unless they asked to share it.
+func (s *slashReply) message(share bool) slack.Msg {
+	msg := slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: strings.Join(s.texts, "\n\n")}
+	if msg.Text == "" {
+		msg.Text = ":white_check_mark: Done"
+	}
+	// Blocks only make sense if they're the whole reply
+	if len(s.texts) == 1 && len(s.blocks) > 0 {
+		msg.Blocks = slack.Blocks{BlockSet: s.blocks}
+	}
+	if share {
+		msg.ResponseType = slack.ResponseTypeInChannel
+		return msg
+	}
+	hint := "Add `share` to the end to post this in the channel"
+	if len(msg.Blocks.BlockSet) > 0 {
+		msg.Blocks.BlockSet = append(msg.Blocks.BlockSet, contextBlock(hint))
+	} else {
+		msg.Text += "\n_" + hint + "_"
+	}
+	return msg
+}
+
+// slashResponse runs a /wordle command: one of the usual commands, or
+// submit to record a result
+func (h *HTTPHandler) slashResponse(cmd slack.SlashCommand) (slack.Msg, error) {
+	text := strings.TrimSpace(cmd.Text)
+	share := false
+	if rest, ok := strings.CutSuffix(text, slashShare); ok && (rest == "" || strings.HasSuffix(rest, " ")) {
+		text, share = strings.TrimSpace(rest), true
+	}
+	fields := strings.Fields(text)
+	name := "help"
+	if len(fields) > 0 {
+		name = strings.ToLower(fields[0])
+		// The rest keeps its lines, which hold the guess grid of a share
+		text = strings.TrimSpace(text[len(fields[0]):])
+		fields = fields[1:]
+	}
+
+	// Slash commands aren't messages, but submitted results need a ts of
+	// their own
+	ts := slashEventPrefix + cmd.TriggerID
+	reply := &slashReply{ChatConnection: h.chat, ts: ts}
+	bot := &HTTPHandler{config: h.config, db: h.db, chat: reply, clock: h.clock}
+	sm := ChatMessage{team: cmd.TeamID, channel: cmd.ChannelID, user: cmd.UserID, text: text, ts: ts}
+	var err error
+	switch name {
+	case "submit":
+		reply.summaries = true
+		err = bot.handleSubmit(sm)
+	case "help":
+		err = bot.handleCommand(sm, name, fields)
+		reply.texts = append(reply.texts, slashSubmitHelp)
+		reply.texts = []string{strings.Join(reply.texts, "\n")}
+	default:
+		err = bot.handleCommand(sm, name, fields)
+	}
+	return reply.message(share), err
+}
+
+// slashAck is the immediate reply to a slash command. help is answered
+// straight away, but anything else can take longer than the 3 seconds Slack
+// waits, so it's queued like an event and answered later through the
+// command's response_url. queued is true when there's new work in the queue.
+func (h *HTTPHandler) slashAck(cmd slack.SlashCommand) (msg slack.Msg, queued bool) {
+	if fields := strings.Fields(cmd.Text); len(fields) == 0 || strings.ToLower(fields[0]) == "help" {
+		msg, err := h.slashResponse(cmd)
+		if err != nil {
+			log.Print(err)
+			msg = slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: slashFailed}
+		}
+		return msg, false
+	}
+
+	body, err := json.Marshal(cmd)
+	if err == nil {
+		queued, err = h.db.putEvent(slashEventPrefix+cmd.TriggerID, body, h.now())
+	}
+	if err != nil {
+		log.Print(err)
+		return slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: slashFailed}, false
+	}
+	return slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: slashWorking}, queued
+}
+
+// respondToSlashCommand runs a queued slash command and sends its reply to
+// the command's response_url
+func (h *HTTPHandler) respondToSlashCommand(cmd slack.SlashCommand) error {
+	msg, err := h.slashResponse(cmd)
+	if err != nil {
+		log.Print(err)
+		msg = slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: slashFailed}
+	}
+	reply := &slack.WebhookMessage{ResponseType: msg.ResponseType, Text: msg.Text}
+	if len(msg.Blocks.BlockSet) > 0 {
+		reply.Blocks = &msg.Blocks
+	}
+	return slack.PostWebhook(cmd.ResponseURL, reply)
+}
+
+// handleSubmit records the result in a submitted share
+func (h *HTTPHandler) handleSubmit(sm ChatMessage) error {
+	user, err := h.chat.NameForUser(sm.user)
+	if err != nil {
+		return err
+	}
+	res := extractMessageResult(sm, user)
+	if res == nil {
+		return h.chat.PostMessage(sm.channel, "I couldn't find a result in that. Paste the share text from the game after /wordle submit")
+	}
+	if ok, err := h.checkResult(sm, res); !ok {
+		return err
+	}
+	if err := h.recordResult(sm, res); err != nil {
+		return err
+	}
+	game := gameFor(*res)
+	return h.chat.PostMessage(sm.channel, ":white_check_mark: Recorded "+game.PuzzleTitle(res.wordlenum)+" "+game.ScoreLabel(res.score))
+}
+
+// handleSlashCommand handles /wordle, verified the same way as events
+func (h *HTTPHandler) handleSlashCommand(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.verifiedBody(w, r)
+	if !ok {
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	cmd, err := slack.SlashCommandParse(r)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	msg, queued := h.slashAck(cmd)
+	if queued {
+		h.kickWorker()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
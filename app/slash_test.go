@@ -0,0 +1,184 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wordleturtle/config"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// slashTriggers numbers slash commands, as their trigger ids
+var slashTriggers int32
+
+// slashRequest builds a signed /wordle request
+func slashRequest(secret, text, responseURL string) *http.Request {
+	form := url.Values{
+		"command":      {"/wordle"},
+		"text":         {text},
+		"team_id":      {"testteam"},
+		"channel_id":   {"testchannel"},
+		"user_id":      {"userid1"},
+		"response_url": {responseURL},
+		"trigger_id":   {fmt.Sprint(atomic.AddInt32(&slashTriggers, 1))},
+	}
+	req := signedRequest(secret, form.Encode())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// slashConfig is config for slash commands whose queue the test runs itself,
+// by sending kicks to a /work that does nothing
+func slashConfig(t *testing.T) *config.BotConfig {
+	work := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(work.Close)
+	return &config.BotConfig{SigningSecret: "signingsecret", TickSecret: "ticksecret", WorkURL: work.URL}
+}
+
+// slashCommand returns the immediate response to a command
+func slashCommand(t *testing.T, h *HTTPHandler, text, responseURL string) slack.Msg {
+	w := httptest.NewRecorder()
+	h.handleSlashCommand(w, slashRequest("signingsecret", text, responseURL))
+	require.Equal(t, http.StatusOK, w.Code)
+	var msg slack.Msg
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&msg))
+	return msg
+}
+
+// slashQueued runs a command that's answered from the queue, and returns
+// what it sent to the response_url
+func slashQueued(t *testing.T, h *HTTPHandler, text string) slack.WebhookMessage {
+	var reply slack.WebhookMessage
+	responses := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&reply))
+		responses++
+	}))
+	defer server.Close()
+
+	msg := slashCommand(t, h, text, server.URL)
+	assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType)
+	assert.Equal(t, slashWorking, msg.Text)
+	require.NoError(t, h.runQueuedEvents())
+	require.Equal(t, 1, responses, text)
+	return reply
+}
+
+func Test_handleSlashCommand_BadSignature(t *testing.T) {
+	h := &HTTPHandler{config: &config.BotConfig{SigningSecret: "signingsecret"}}
+
+	w := httptest.NewRecorder()
+	h.handleSlashCommand(w, slashRequest("wrongsecret", "stats", ""))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func Test_handleSlashCommand_Stats(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	h := &HTTPHandler{config: slashConfig(t), db: db, chat: mockSlack}
+
+	res := makeResult("userid1", "sean", 917, 3)
+	res.team, res.channel = "testteam", "testchannel"
+	require.NoError(t, db.putResult(res))
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+
+	reply := slashQueued(t, h, "stats")
+	assert.Equal(t, slack.ResponseTypeEphemeral, reply.ResponseType)
+	assert.True(t, strings.HasPrefix(reply.Text, ":bar_chart: sean's Wordle stats :bar_chart:"), reply.Text)
+	assert.True(t, strings.HasSuffix(reply.Text, "_Add `share` to the end to post this in the channel_"), reply.Text)
+
+	reply = slashQueued(t, h, "stats share")
+	assert.Equal(t, slack.ResponseTypeInChannel, reply.ResponseType)
+	assert.NotContains(t, reply.Text, "Add `share`")
+
+	// Nothing was posted to the channel
+	mockSlack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
+
+func Test_handleSlashCommand_Help(t *testing.T) {
+	h := &HTTPHandler{config: &config.BotConfig{SigningSecret: "signingsecret"}, chat: new(MockSlack)}
+
+	// Quick enough to answer straight away
+	msg := slashCommand(t, h, "", "")
+	assert.True(t, strings.HasPrefix(msg.Text, "Supported commands are:\nhelp - display this help text"), msg.Text)
+	assert.Contains(t, msg.Text, "\n"+slashSubmitHelp+"\n")
+}
+
+func Test_handleSlashCommand_Submit(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	var channel strings.Builder
+	h := &HTTPHandler{
+		config: slashConfig(t),
+		db:     db,
+		chat:   &replConnection{out: &channel, users: []string{"userid1", "userid2"}},
+		clock:  newFakeClock(DayForWordle(1283).Add(9 * time.Hour)),
+	}
+
+	reply := slashQueued(t, h, "submit Wordle 1,283 3/6\n\n⬛🟨⬛⬛⬛\n🟩🟩⬛🟨⬛\n🟩🟩🟩🟩🟩")
+	assert.Equal(t, slack.ResponseTypeEphemeral, reply.ResponseType)
+	assert.Contains(t, reply.Text, ":white_check_mark: Recorded Wordle #1283 3/6")
+
+	dailies, err := db.getDailyResults(testScope, "Wordle", 1283)
+	require.NoError(t, err)
+	require.Len(t, dailies, 1)
+	assert.Equal(t, []string{"-Y---", "GG-Y-", "GGGGG"}, dailies[0].grid)
+	// The live summary still goes to the channel
+	assert.Contains(t, channel.String(), "Current Results for Wordle #1283:\n3/6: userid1")
+
+	reply = slashQueued(t, h, "submit Wordle 9,999 1/6")
+	assert.Equal(t, "Wordle #9999 isn't out yet here, so I can't count it. Today's is Wordle #1283\n_Add `share` to the end to post this in the channel_", reply.Text)
+
+	reply = slashQueued(t, h, "submit hello")
+	assert.Contains(t, reply.Text, "I couldn't find a result in that")
+}
+
+func Test_handleSlashCommand_SubmitThenOverride(t *testing.T) {
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	var channel strings.Builder
+	h := &HTTPHandler{
+		config: slashConfig(t),
+		db:     db,
+		chat:   &replConnection{out: &channel, users: []string{"userid1", "userid2"}},
+		clock:  newFakeClock(DayForWordle(1283).Add(time.Hour)),
+	}
+
+	// Each submitted result gets a ts of its own. Yesterday's still counts
+	// this early.
+	slashQueued(t, h, "submit Wordle 1,282 4/6")
+	slashQueued(t, h, "submit Wordle 1,283 3/6")
+	first, err := db.getDailyResults(testScope, "Wordle", 1282)
+	require.NoError(t, err)
+	second, err := db.getDailyResults(testScope, "Wordle", 1283)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	require.Len(t, second, 1)
+	assert.True(t, strings.HasPrefix(second[0].ts, slashEventPrefix), second[0].ts)
+	assert.NotEqual(t, first[0].ts, second[0].ts)
+	found, err := db.getResultByTs(testScope, second[0].ts)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, 1283, found.wordlenum)
+
+	// The announcement goes in the summary's thread for everyone to see,
+	// and only the command's own reply is private
+	summaryTs, err := db.getSummaryTs(testScope, "Wordle", 1283)
+	require.NoError(t, err)
+	reply := slashQueued(t, h, "override <@userid1> 1283 2")
+	assert.Equal(t, slack.ResponseTypeEphemeral, reply.ResponseType)
+	assert.Contains(t, channel.String(), fmt.Sprintf("[in reply to %s]: :memo: userid1 changed userid1's Wordle #1283 from 3/6 to 2/6", summaryTs))
+	assert.NotContains(t, reply.Text, ":memo:")
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"log"
+	"wordleturtle/config"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// SocketModeHandler receives events over a Socket Mode websocket instead of
+// an HTTP endpoint, so it can run without a public URL. Events go through
+// the same queue and pipeline as HTTPHandler.
+type SocketModeHandler struct {
+	Handler
+	bot    *HTTPHandler
+	client *socketmode.Client
+}
+
+func newSocketModeHandler(bot *HTTPHandler, api *slack.Client) *SocketModeHandler {
+	return &SocketModeHandler{bot: bot, client: socketmode.New(api)}
+}
+
+// Init initializes handler
+func (h *SocketModeHandler) Init(c *config.BotConfig) {
+	bot := &HTTPHandler{}
+	bot.setup(c, NewSlackAPIConnection(c.SlackBotToken, c.MembersCacheTTL))
+	*h = *newSocketModeHandler(bot, slack.New(c.SlackBotToken, slack.OptionAppLevelToken(c.SlackAppToken)))
+}
+
+// Start connects to Slack and handles events until the connection fails
+func (h *SocketModeHandler) Start() error {
+	// Nothing calls /tick when there's no endpoint
+	go h.bot.runTicker(h.bot.config.TickInterval)
+	return h.run(context.Background())
+}
+
+func (h *SocketModeHandler) run(ctx context.Context) error {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt := <-h.client.Events:
+				h.handleSocketEvent(evt)
+			}
+		}
+	}()
+	return h.client.RunContext(ctx)
+}
+
+func (h *SocketModeHandler) handleSocketEvent(evt socketmode.Event) {
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		log.Println("Connecting to Slack with Socket Mode")
+	case socketmode.EventTypeConnectionError:
+		log.Printf("Socket Mode connection failed: %v", evt.Data)
+	case socketmode.EventTypeEventsAPI:
+		// Ack first, as over HTTP, so Slack doesn't retry
+		h.client.Ack(*evt.Request)
+
+		eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			return
+		}
+		callback, ok := eventsAPIEvent.Data.(*slackevents.EventsAPICallbackEvent)
+		if !ok {
+			return
+		}
+		if evt.Request.RetryAttempt > 0 {
+			log.Printf("Slack retry %d of %s: %s", evt.Request.RetryAttempt, callback.EventID, evt.Request.RetryReason)
+		}
+		queued, err := h.bot.db.putEvent(callback.EventID, evt.Request.Payload, h.bot.now())
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if queued {
+			if err := h.bot.runQueuedEvents(); err != nil {
+				log.Println(err)
+			}
+		}
+	case socketmode.EventTypeSlashCommand:
+		cmd, ok := evt.Data.(slack.SlashCommand)
+		if !ok {
+			return
+		}
+		// The ack says we're working on it, unless the answer is quick
+		msg, queued := h.bot.slashAck(cmd)
+		h.client.Ack(*evt.Request, msg)
+		if queued {
+			if err := h.bot.runQueuedEvents(); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
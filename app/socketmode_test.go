@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSocketMode stands in for Slack: it hands out a websocket URL, then
+// sends each envelope over the socket and reports the envelope ids acked
+func fakeSocketMode(t *testing.T, envelopes []string) (*httptest.Server, chan string) {
+	acks := make(chan string, len(envelopes))
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xapp-test", r.Header.Get("Authorization"))
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/link"
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "url": url})
+	})
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"hello","num_connections":1}`))
+		for _, envelope := range envelopes {
+			conn.WriteMessage(websocket.TextMessage, []byte(envelope))
+		}
+		for {
+			var ack struct {
+				EnvelopeID string `json:"envelope_id"`
+			}
+			if err := conn.ReadJSON(&ack); err != nil {
+				return
+			}
+			acks <- ack.EnvelopeID
+		}
+	})
+	return server, acks
+}
+
+func Test_SocketModeHandler(t *testing.T) {
+	envelope := `{"type":"events_api","envelope_id":"env1","payload":` + testEventBody + `}`
+	// Slack retrying the same event in a new envelope
+	retry := `{"type":"events_api","envelope_id":"env2","retry_attempt":1,"payload":` + testEventBody + `}`
+	server, acks := fakeSocketMode(t, []string{envelope, retry})
+	defer server.Close()
+
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "wordles"), MigrationParams{})
+	require.NoError(t, err)
+	mockSlack := new(MockSlack)
+	mockSlack.On("NameForUser", "userid1").Return("sean", nil)
+	posted := make(chan string, 2)
+	mockSlack.On("PostMessage", "testchannel", mock.Anything).Run(func(args mock.Arguments) {
+		posted <- args.String(1)
+	}).Return(nil)
+
+	api := slack.New("xoxb-test", slack.OptionAppLevelToken("xapp-test"), slack.OptionAPIURL(server.URL+"/"))
+	h := newSocketModeHandler(&HTTPHandler{db: db, chat: mockSlack}, api)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.run(ctx)
+
+	for _, want := range []string{"env1", "env2"} {
+		select {
+		case got := <-acks:
+			assert.Equal(t, want, got)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s was never acked", want)
+		}
+	}
+	select {
+	case msg := <-posted:
+		assert.True(t, strings.HasPrefix(msg, "Supported commands are:"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command was never handled")
+	}
+
+	// Give a duplicate post a chance to show up
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, posted)
+}
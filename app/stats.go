@@ -0,0 +1,129 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// PlayerStats summarises every result a player has posted for a game
+type PlayerStats struct {
+	played       int
+	solved       int
+	hardmode     int
+	totalScore   int
+	totalPoints  int
+	distribution []int
+}
+
+func computeStats(game Game, results []Result) PlayerStats {
+	s := PlayerStats{distribution: make([]int, len(game.Buckets()))}
+	for _, r := range results {
+		s.played++
+		if game.Solved(r.score) {
+			s.solved++
+		}
+		if r.hardmode > 0 {
+			s.hardmode++
+		}
+		s.totalScore += r.score
+		s.totalPoints += game.Points(r.score)
+		if bucket, ok := bucketFor(game, r.score); ok {
+			s.distribution[bucket]++
+		}
+	}
+	return s
+}
+
+func percent(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(of)
+}
+
+func (s PlayerStats) averageScore() float64 {
+	if s.played == 0 {
+		return 0
+	}
+	return float64(s.totalScore) / float64(s.played)
+}
+
+func (s PlayerStats) averagePoints() float64 {
+	if s.played == 0 {
+		return 0
+	}
+	return float64(s.totalPoints) / float64(s.played)
+}
+
+// channelRank returns where userId places in the channel by average points,
+// and how many players there are
+func channelRank(game Game, results []Result, userId string) (int, int) {
+	byUser := make(map[string][]Result)
+	for _, r := range results {
+		byUser[r.userId] = append(byUser[r.userId], r)
+	}
+
+	type ranking struct {
+		userId  string
+		average float64
+	}
+	rankings := make([]ranking, 0, len(byUser))
+	for user, userResults := range byUser {
+		rankings = append(rankings, ranking{userId: user, average: computeStats(game, userResults).averagePoints()})
+	}
+	sort.Slice(rankings, func(i, j int) bool {
+		if rankings[i].average == rankings[j].average {
+			return rankings[i].userId < rankings[j].userId
+		}
+		return rankings[i].average > rankings[j].average
+	})
+
+	for i, r := range rankings {
+		if r.userId == userId {
+			return i + 1, len(rankings)
+		}
+	}
+	return 0, len(rankings)
+}
+
+func getStatsPost(name string, game Game, s PlayerStats, rank, players int) string {
+	if s.played == 0 {
+		return fmt.Sprintf("%s hasn't played any %s yet", name, game.Name())
+	}
+
+	msg := fmt.Sprintf(":bar_chart: %s's %s stats :bar_chart:\n", name, game.Name())
+	msg += fmt.Sprintf("Played: %d\n", s.played)
+	msg += fmt.Sprintf("Average: %.2f (%s)\n", s.averageScore(), game.ScoreLabel(int(math.Round(s.averageScore()))))
+	msg += fmt.Sprintf("Win %%: %.0f\n", percent(s.solved, s.played))
+	msg += fmt.Sprintf("Hard mode: %.0f%%\n", percent(s.hardmode, s.played))
+	if rank > 0 {
+		msg += fmt.Sprintf("Rank: %d of %d\n", rank, players)
+	}
+
+	// Scale the bars so the most common bucket fills the width
+	const barWidth = 20
+	most := 0
+	for _, count := range s.distribution {
+		if count > most {
+			most = count
+		}
+	}
+
+	tw := table.NewWriter()
+	tw.AppendHeader(table.Row{"Score", "Count", ""})
+	for i, bucket := range game.Buckets() {
+		count := s.distribution[i]
+		bar := strings.Repeat("█", int(math.Ceil(float64(count*barWidth)/float64(most))))
+		tw.AppendRow(table.Row{bucket, count, bar})
+	}
+	tw.Style().Format = table.FormatOptions{
+		Header: text.FormatDefault,
+	}
+
+	return msg + "```\n" + tw.Render() + "\n```"
+}
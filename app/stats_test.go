@@ -0,0 +1,48 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_computeStats(t *testing.T) {
+	results := []Result{
+		makeResult("userid1", "sean", 100, 3),
+		makeResult("userid1", "sean", 101, 3),
+		makeResult("userid1", "sean", 102, 7),
+		makeResult("userid1", "sean", 103, 4),
+	}
+	results[1].hardmode = 1
+
+	s := computeStats(wordle{}, results)
+	assert.Equal(t, 4, s.played)
+	assert.Equal(t, 3, s.solved)
+	assert.Equal(t, 1, s.hardmode)
+	assert.Equal(t, []int{0, 0, 2, 1, 0, 0, 1}, s.distribution)
+	assert.InDelta(t, 4.25, s.averageScore(), 0.001)
+
+	// Scores stored before shares were checked don't fit any bucket
+	s = computeStats(wordle{}, []Result{makeResult("userid1", "sean", 104, 0), makeResult("userid1", "sean", 105, 9)})
+	assert.Equal(t, 2, s.played)
+	assert.Equal(t, []int{0, 0, 0, 0, 0, 0, 0}, s.distribution)
+
+	s = computeStats(wordle{}, nil)
+	assert.Equal(t, 0.0, s.averageScore())
+	assert.Equal(t, "sean hasn't played any Wordle yet", getStatsPost("sean", wordle{}, s, 0, 0))
+}
+
+func Test_channelRank(t *testing.T) {
+	results := []Result{
+		makeResult("userid1", "sean", 100, 5),
+		makeResult("userid2", "lara", 100, 2),
+		makeResult("userid3", "kim", 100, 4),
+	}
+
+	rank, players := channelRank(wordle{}, results, "userid3")
+	assert.Equal(t, 2, rank)
+	assert.Equal(t, 3, players)
+
+	rank, _ = channelRank(wordle{}, results, "nobody")
+	assert.Equal(t, 0, rank)
+}
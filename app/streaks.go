@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+)
+
+// streakMilestones are the streak lengths worth announcing at the deadline
+var streakMilestones = []int{7, 30, 50, 100, 200, 365, 500, 1000}
+
+// Streaks are a player's runs of consecutive days
+type Streaks struct {
+	// days played in a row
+	currentPlayed, bestPlayed int
+	// days played in a row without an X
+	currentSolved, bestSolved int
+}
+
+// computeStreaks works out a player's streaks as of puzzle number today.
+// Puzzle numbers go up by one a day, so consecutive numbers are consecutive
+// days. A streak is still current if the last result is from today or
+// yesterday, since today's may not have been posted yet.
+func computeStreaks(game Game, results []Result, today int) Streaks {
+	sort.Slice(results, func(i, j int) bool { return results[i].wordlenum < results[j].wordlenum })
+
+	var s Streaks
+	played, solved := 0, 0
+	last := 0
+	for _, r := range results {
+		if r.wordlenum > today {
+			continue
+		}
+		if played > 0 && r.wordlenum == last+1 {
+			played++
+		} else {
+			played = 1
+			solved = 0
+		}
+		if game.Solved(r.score) {
+			solved++
+		} else {
+			solved = 0
+		}
+		last = r.wordlenum
+
+		if played > s.bestPlayed {
+			s.bestPlayed = played
+		}
+		if solved > s.bestSolved {
+			s.bestSolved = solved
+		}
+	}
+	if played > 0 && last >= today-1 {
+		s.currentPlayed = played
+		s.currentSolved = solved
+	}
+	return s
+}
+
+func isStreakMilestone(days int) bool {
+	for _, m := range streakMilestones {
+		if days == m {
+			return true
+		}
+	}
+	return false
+}
+
+func getStreakPost(name string, game Game, s Streaks) string {
+	return fmt.Sprintf(":fire: %s's %s streaks :fire:\nPlayed: %s in a row (best %d)\nWithout an X: %s in a row (best %d)",
+		name, game.Name(), pluralize(s.currentPlayed, "day"), s.bestPlayed, pluralize(s.currentSolved, "day"), s.bestSolved)
+}
+
+// getStreakMilestones lists the players who hit a streak milestone with
+// today's puzzle, for the end of day post
+func getStreakMilestones(db DB, sc scope, game Game, wordlenum int, dailies []Result) ([]string, error) {
+	messages := make([]string, 0)
+	for _, r := range dailies {
+		history, err := db.getPlayerResults(sc, game.Name(), r.userId)
+		if err != nil {
+			return nil, err
+		}
+		s := computeStreaks(game, history, wordlenum)
+		if isStreakMilestone(s.currentPlayed) {
+			messages = append(messages, fmt.Sprintf(":fire: %s has played %d days in a row!", r.displayName, s.currentPlayed))
+		}
+		if game.Solved(r.score) && s.currentSolved != s.currentPlayed && isStreakMilestone(s.currentSolved) {
+			messages = append(messages, fmt.Sprintf(":fire: %s has gone %d days without an X!", r.displayName, s.currentSolved))
+		}
+	}
+	return messages, nil
+}
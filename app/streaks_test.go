@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_computeStreaks(t *testing.T) {
+	results := []Result{
+		makeResult("userid1", "sean", 100, 3),
+		makeResult("userid1", "sean", 101, 4),
+		makeResult("userid1", "sean", 102, 5),
+		makeResult("userid1", "sean", 103, 7),
+		makeResult("userid1", "sean", 104, 2),
+		// missed 105
+		makeResult("userid1", "sean", 106, 3),
+		makeResult("userid1", "sean", 107, 3),
+	}
+
+	s := computeStreaks(wordle{}, results, 108)
+	assert.Equal(t, Streaks{currentPlayed: 2, bestPlayed: 5, currentSolved: 2, bestSolved: 3}, s)
+
+	// Missing yesterday ends the current streaks, but not the best
+	s = computeStreaks(wordle{}, results, 109)
+	assert.Equal(t, Streaks{currentPlayed: 0, bestPlayed: 5, currentSolved: 0, bestSolved: 3}, s)
+
+	s = computeStreaks(wordle{}, nil, 109)
+	assert.Equal(t, Streaks{}, s)
+}
@@ -13,15 +13,6 @@ import (
 	"github.com/jedib0t/go-pretty/v6/text"
 )
 
-func isCommandMessage(message string) (bool, string) {
-	matcher := regexp.MustCompile(`^WordleTurtle (help|leaderboard)`)
-	matches := matcher.FindSubmatch([]byte(message))
-	if len(matches) == 0 {
-		return false, ""
-	}
-	return true, string(matches[1])
-}
-
 func extractWordleResult(message string) *Result {
 	matcher := regexp.MustCompile(`^\s*Wordle ([\d,]+).* (\d|x|X)/\d(\*)?`)
 	matches := matcher.FindSubmatch([]byte(message))
@@ -40,11 +31,80 @@ func extractWordleResult(message string) *Result {
 		r.score, _ = strconv.Atoi(scoreStr)
 	}
 	r.hardmode = len(matches[3])
+	if !(wordle{}).ValidScore(r.score) {
+		log.Printf("Rejecting wordle %d: score %d/6", r.wordlenum, r.score)
+		return nil
+	}
+
+	r.grid = extractGuessGrid(message)
+	if err := validateGuessGrid(r.grid, r.score); err != nil {
+		log.Printf("Rejecting wordle %d: %v", r.wordlenum, err)
+		return nil
+	}
 	return &r
 }
 
+const (
+	colorCorrect = 'G'
+	colorPresent = 'Y'
+	colorAbsent  = '-'
+)
+
+// guessColors maps the squares in a share onto our compact color pattern.
+// Orange/blue are high contrast mode.
+var guessColors = map[rune]rune{
+	'🟩': colorCorrect,
+	'🟧': colorCorrect,
+	'🟨': colorPresent,
+	'🟦': colorPresent,
+	'⬜': colorAbsent,
+	'⬛': colorAbsent,
+}
+
+// extractGuessGrid pulls the guess rows, like "-Y--G", out of a share
+func extractGuessGrid(message string) []string {
+	return extractEmojiRows(message, guessColors)
+}
+
+// validateGuessGrid checks the grid agrees with the claimed score. A missing
+// grid is allowed since people often only paste the header line.
+func validateGuessGrid(grid []string, score int) error {
+	if len(grid) == 0 {
+		return nil
+	}
+	for i, row := range grid {
+		if len(row) != 5 {
+			return fmt.Errorf("guess %d has %d letters", i+1, len(row))
+		}
+	}
+	solved := strings.Repeat(string(colorCorrect), 5)
+	if score > 6 {
+		if len(grid) != 6 {
+			return fmt.Errorf("failed score with %d guesses", len(grid))
+		}
+		for _, row := range grid {
+			if row == solved {
+				return fmt.Errorf("failed score with a solved row")
+			}
+		}
+		return nil
+	}
+	if len(grid) != score {
+		return fmt.Errorf("score %d/6 with %d guesses", score, len(grid))
+	}
+	for i, row := range grid {
+		if (row == solved) != (i == len(grid)-1) {
+			return fmt.Errorf("score %d/6 but guess %d is %s", score, i+1, row)
+		}
+	}
+	return nil
+}
+
 func getLeaders(results []Result) []Result {
-	bestscore := 8
+	if len(results) == 0 {
+		return nil
+	}
+	bestscore := results[0].score
 	for _, r := range results {
 		if r.score < bestscore {
 			bestscore = r.score
@@ -82,34 +142,40 @@ func leaderString(leaders []Result) string {
 	return namesString(names)
 }
 
-func getWordlePost(current Result, dailies []Result, users, missing []string) string {
-	leaders := getLeaders(dailies)
-	leaderStr := leaderString(leaders)
-
+// getWordlePost is the live summary for a puzzle, which becomes the final
+// one once everyone has played
+func getWordlePost(dailies []Result, missing []string) RichMessage {
 	// All users played (except the bot)?
-	summaryMsg := makeSummaryPositionMessage(dailies)
-
 	if len(missing) == 0 {
-		return fmt.Sprintf(":confetti_ball: Congratulations to %s! :confetti_ball:\nFinal %s", leaderStr, summaryMsg)
+		return finalSummaryPost(dailies, nil)
 	}
 
-	summary := fmt.Sprintf("Current %s", summaryMsg)
 	// this got too long, so removing it to clean up the content
 	//"Waiting on: %s :hourglass:", summaryMsg, strings.Join(missing, ", "))
+	return RichMessage{
+		text:   fmt.Sprintf("Current %s", makeSummaryPositionMessage(dailies)),
+		blocks: makeSummaryPositionBlocks("Current", dailies),
+	}
+}
 
-	res := getSpecialDayAffirmation(current.score)
-	if res != "" {
+// getWordleReply is what we say in reply to someone's result, if anything
+func getWordleReply(current Result, dailies []Result, now time.Time) string {
+	score := affirmationScore(current)
+	if res := getSpecialDayAffirmation(score, now); res != "" {
 		// It's a special day (like christmas)
-		summary = fmt.Sprintf("%s\n\n%s", res, summary)
-	} else if len(dailies) == 1 {
+		return res
+	}
+	if len(dailies) == 1 {
 		// First person to play, give them a little earlybird message
-		summary = fmt.Sprintf("%s\n\n%s", getEarlyBirdMessage(current.score), summary)
-	} else if userInLead(current, dailies) {
-		summary = fmt.Sprintf("%s\n\n%s", getAffirmation(current.score), summary)
-	} else if userInLast(current, dailies) {
-		summary = fmt.Sprintf("%s\n\n%s", getConsolation(), summary)
+		return getEarlyBirdMessage(score)
+	}
+	if userInLead(current, dailies) {
+		return getAffirmation(score)
+	}
+	if userInLast(current, dailies) {
+		return getConsolation()
 	}
-	return summary
+	return ""
 }
 
 func userInLead(result Result, results []Result) bool {
@@ -130,41 +196,42 @@ func userInLast(result Result, results []Result) bool {
 	return result.score == results[len(results)-1].score
 }
 
-func makeSummaryPositionMessage(results []Result) string {
-	if len(results) == 0 {
-		return "No plays yet."
-	}
-	sort.Slice(results, func(i, j int) bool { return results[i].score < results[j].score })
+// scoreGroup is everyone who got the same score
+type scoreGroup struct {
+	score int
+	names []string
+}
 
-	message := fmt.Sprintf("Results for Wordle #%d:\n", results[0].wordlenum)
+// groupByScore sorts results best first and groups equal scores together
+func groupByScore(results []Result) []scoreGroup {
+	sort.Slice(results, func(i, j int) bool { return results[i].score < results[j].score })
 
-	appendPosition := func(pos int, users []string) {
-		if len(users) == 0 {
-			return
-		}
-		posStr := fmt.Sprint(pos)
-		if pos > 6 {
-			posStr = "x"
+	groups := make([]scoreGroup, 0)
+	for _, r := range results {
+		if len(groups) == 0 || groups[len(groups)-1].score != r.score {
+			groups = append(groups, scoreGroup{score: r.score})
 		}
-		message += fmt.Sprintf("%s/6: %s\n", posStr, strings.Join(users, ", "))
+		last := &groups[len(groups)-1]
+		last.names = append(last.names, r.displayName)
+	}
+	return groups
+}
+
+func makeSummaryPositionMessage(results []Result) string {
+	if len(results) == 0 {
+		return "No plays yet."
 	}
 
-	currentPos := 0
-	currentUsers := make([]string, 0)
-	for _, r := range results {
-		if r.score > currentPos && len(currentUsers) > 0 {
-			appendPosition(currentPos, currentUsers)
-			currentUsers = make([]string, 0)
-		}
-		currentPos = r.score
-		currentUsers = append(currentUsers, r.displayName)
+	game := gameFor(results[0])
+	message := fmt.Sprintf("Results for %s:\n", game.PuzzleTitle(results[0].wordlenum))
+	for _, group := range groupByScore(results) {
+		message += fmt.Sprintf("%s: %s\n", game.ScoreLabel(group.score), strings.Join(group.names, ", "))
 	}
-	appendPosition(currentPos, currentUsers)
 
 	return message
 }
 
-func getMissingPlayers(slack SlackConnection, userIds []string, results []Result) []string {
+func getMissingPlayers(chat ChatConnection, userIds []string, results []Result) []string {
 	log.Printf("Users = %s", strings.Join(userIds, ", "))
 	missing := make([]string, 0)
 OUTER:
@@ -183,7 +250,7 @@ OUTER:
 
 	translated := make([]string, 0, len(missing))
 	for _, u := range missing {
-		user, err := slack.NameForUser(u)
+		user, err := chat.NameForUser(u)
 		if err != nil {
 			continue
 		}
@@ -197,14 +264,37 @@ OUTER:
 	return translated
 }
 
-func getLeaderBoardPost(db DB, slack SlackConnection, wordlenum int, channel string) (string, error) {
-	// Get latest wordlenum
-	// Get results for previous 7 wordles
+func getLeaderBoardPost(db DB, chat ChatConnection, sc scope, game Game, period leaderboardPeriod) (RichMessage, error) {
+	// Get results for every puzzle in the period
 	// tabulate scores by user
 	// format post text
-	users, err := slack.GetUsers(channel)
+	users, err := chat.GetUsers(sc.channel)
+	if err != nil {
+		return RichMessage{}, err
+	}
+
+	results, err := db.getResultsInRange(sc, game.Name(), period.from, period.to)
 	if err != nil {
-		return "", err
+		return RichMessage{}, err
+	}
+	players, err := db.getParticipation(sc)
+	if err != nil {
+		return RichMessage{}, err
+	}
+
+	// All time starts from the first puzzle anyone played
+	from := period.from
+	if from <= 0 {
+		from = period.to
+		for _, result := range results {
+			if result.wordlenum < from {
+				from = result.wordlenum
+			}
+		}
+	}
+	// expected says whether someone was meant to play a puzzle
+	expected := func(userId string, wordlenum int) bool {
+		return players[userId].expected(game.DayForPuzzle(wordlenum))
 	}
 
 	type LeaderboardScore struct {
@@ -215,31 +305,37 @@ func getLeaderBoardPost(db DB, slack SlackConnection, wordlenum int, channel str
 
 	userScores := make(map[string]*LeaderboardScore)
 
-	LOOKBACK_DAYS := 7
+	// One column per bucket, plus the turkeys
+	buckets := game.Buckets()
 	// Pre-seed userScores
 	for _, user := range users {
+		if players[user].state == stateOptedOut {
+			continue
+		}
 		userScores[user] = &LeaderboardScore{
 			userId:      user,
 			totalScore:  0,
-			scoreMatrix: make([]int, 8),
+			scoreMatrix: make([]int, len(buckets)+1),
+		}
+		// Start with a turkey for every day they were meant to play
+		for num := from; num <= period.to; num++ {
+			if expected(user, num) {
+				userScores[user].scoreMatrix[len(buckets)]++
+			}
 		}
-		// Start with all turkeys
-		userScores[user].scoreMatrix[len(userScores[user].scoreMatrix)-1] = LOOKBACK_DAYS
 	}
 
-	for i := 0; i < LOOKBACK_DAYS; i++ {
-		dailies, err := db.getDailyResults(wordlenum - i)
-		if err != nil {
-			return "", err
+	for _, result := range results {
+		us, ok := userScores[result.userId]
+		if !ok {
+			continue
 		}
-		for _, result := range dailies {
-			us, ok := userScores[result.userId]
-			if !ok {
-				continue
-			}
-			us.totalScore += 8 - result.score
-			us.scoreMatrix[result.score-1] += 1
-			us.scoreMatrix[len(us.scoreMatrix)-1] -= 1
+		us.totalScore += game.Points(result.score)
+		if bucket, ok := bucketFor(game, result.score); ok {
+			us.scoreMatrix[bucket] += 1
+		}
+		if expected(result.userId, result.wordlenum) {
+			us.scoreMatrix[len(buckets)] -= 1
 		}
 	}
 
@@ -253,37 +349,54 @@ func getLeaderBoardPost(db DB, slack SlackConnection, wordlenum int, channel str
 	})
 
 	tw := table.NewWriter()
-	rowHeader := table.Row{"Player", "Score", "1s", "2s", "3s", "4s", "5s", "6s", "Xs", "Turkey"}
+	rowHeader := table.Row{"Player", "Score"}
+	for _, bucket := range buckets {
+		rowHeader = append(rowHeader, bucket)
+	}
+	rowHeader = append(rowHeader, "Turkey")
 	tw.AppendHeader(rowHeader)
 
 	missing := []string{}
+	standings := []leaderboardRow{}
 
 	for _, score := range scores {
-		player, err := slack.NameForUser(score.userId)
+		player, err := chat.NameForUser(score.userId)
 		if err != nil {
-			return "", err
+			return RichMessage{}, err
 		}
 
 		if player == "WordleTurtle" {
 			continue
 		}
 		if score.totalScore == 0 {
-			missing = append(missing, player)
+			// Only turkeys if they were meant to be playing
+			if score.scoreMatrix[len(buckets)] > 0 {
+				missing = append(missing, player)
+			}
 			continue
 		}
 
-		m := score.scoreMatrix
-		tw.AppendRow(table.Row{player, score.totalScore, m[0], m[1], m[2], m[3], m[4], m[5], m[6], m[7]})
+		row := table.Row{player, score.totalScore}
+		for _, count := range score.scoreMatrix {
+			row = append(row, count)
+		}
+		tw.AppendRow(row)
+		standings = append(standings, leaderboardRow{player: player, score: score.totalScore, counts: score.scoreMatrix})
 	}
 
 	tw.Style().Format = table.FormatOptions{
 		Header: text.FormatDefault,
 	}
 
-	msg := "```\n" + tw.Render() + "\n```"
-	msg += fmt.Sprintf("\n:turkey: %s forgot to show up!", namesString(missing))
+	heading := period.heading(game)
+	msg := heading + "\n```\n" + tw.Render() + "\n```"
+	turkeys := ""
+	if len(missing) > 0 {
+		turkeys = fmt.Sprintf(":turkey: %s forgot to show up!", namesString(missing))
+		msg += "\n" + turkeys
+	}
 
-	return msg, nil
+	return RichMessage{text: msg, blocks: leaderboardBlocks(heading, buckets, standings, turkeys)}, nil
 }
 
 func DefaultLocation() *time.Location {
@@ -295,18 +408,13 @@ func NowDefault() time.Time {
 	return time.Now().In(DefaultLocation())
 }
 
+// wordleEpoch is a known day to count Wordles from
+var wordleEpoch = dailyEpoch{num: 1283, year: 2024, month: 12, date: 23}
+
 func DayForWordle(wordleNum int) time.Time {
-	// This might get out of sync at some point if a day ever gets skipped
-	sentinel := time.Date(2024, time.December, 23, 0, 0, 0, 0, DefaultLocation())
-	sentinelWordle := 1283
-	return sentinel.AddDate(0, 0, wordleNum-sentinelWordle)
+	return wordleEpoch.DayForPuzzle(wordleNum)
 }
 
 func WordleForDay(now time.Time) int {
-	// This might get out of sync at some point if a day ever gets skipped
-	sentinel := time.Date(2024, time.December, 23, 0, 0, 0, 0, DefaultLocation())
-	sentinelWordle := 1283
-	// Daylight savings can probably mess this up...
-	elapsedDays := now.Sub(sentinel).Hours() / 24
-	return sentinelWordle + int(elapsedDays)
+	return wordleEpoch.PuzzleForDay(now)
 }
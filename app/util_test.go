@@ -35,6 +35,40 @@ func TestExtractWordle_NoMatch(t *testing.T) {
 
 	res = extractWordleResult("Reshare of\nWordle 867 X/6")
 	assert.Nil(t, res)
+
+	// Scores Wordle can't give, which have no grid to check
+	assert.Nil(t, extractWordleResult("Wordle 1283 0/6"))
+	assert.Nil(t, extractWordleResult("Wordle 1283 9/6"))
+}
+
+func TestExtractWordle_Grid(t *testing.T) {
+	res := extractWordleResult("Wordle 1,283 3/6*\n\n⬜🟨⬜⬜⬜\n🟩🟩⬜🟨⬜\n🟩🟩🟩🟩🟩")
+	assert.Equal(t, 1283, res.wordlenum)
+	assert.Equal(t, 3, res.score)
+	assert.Equal(t, []string{"-Y---", "GG-Y-", "GGGGG"}, res.grid)
+	assert.Equal(t, 2, res.greens(2))
+
+	// Slack rewrites the squares as shortcodes
+	res = extractWordleResult("Wordle 1,283 2/6\n\n:large_green_square::white_large_square::large_yellow_square::black_large_square::black_large_square:\n:large_green_square::large_green_square::large_green_square::large_green_square::large_green_square:")
+	assert.Equal(t, []string{"G-Y--", "GGGGG"}, res.grid)
+
+	res = extractWordleResult("Wordle 867 X/6\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜\n🟩🟩🟩🟩⬜")
+	assert.Equal(t, 7, res.score)
+	assert.Len(t, res.grid, 6)
+}
+
+func TestExtractWordle_GridMismatch(t *testing.T) {
+	// Too few rows for the score
+	res := extractWordleResult("Wordle 1283 3/6\n⬜🟨⬜⬜⬜\n🟩🟩🟩🟩🟩")
+	assert.Nil(t, res)
+
+	// Last row isn't solved
+	res = extractWordleResult("Wordle 1283 2/6\n⬜🟨⬜⬜⬜\n🟩🟩🟩🟨🟩")
+	assert.Nil(t, res)
+
+	// Failed, but claims a solved row
+	res = extractWordleResult("Wordle 1283 X/6\n⬜⬜⬜⬜⬜\n🟩🟩🟩🟩🟩\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜")
+	assert.Nil(t, res)
 }
 
 func Test_makeSummaryPositionMessage(t *testing.T) {
@@ -73,6 +107,8 @@ func Test_WordleForDay(t *testing.T) {
 		{inputs: time.Date(2024, 12, 23, 0, 0, 0, 0, DefaultLocation()), expected: 1283},
 		{inputs: time.Date(2025, 12, 23, 0, 0, 0, 0, DefaultLocation()), expected: 1648},
 		{inputs: time.Date(2029, 1, 1, 0, 0, 0, 0, DefaultLocation()), expected: 2753},
+		// Daylight savings time
+		{inputs: time.Date(2024, 10, 14, 0, 0, 0, 0, DefaultLocation()), expected: 1213},
 	}
 
 	for _, testcase := range inputs {
@@ -93,3 +129,20 @@ func Test_getWordlePost(t *testing.T) {
 	assert.Equal(t, expected, res)
 }
 */
+
+func Test_getWordleReply_SpecialDay(t *testing.T) {
+	result := makeResult("userid1", "sean", 1285, 3)
+	christmas := time.Date(2024, 12, 25, 9, 0, 0, 0, DefaultLocation())
+	reply := getWordleReply(result, []Result{result}, christmas)
+	assert.Contains(t, []string{
+		"It's a Christmas Miracle!! :christmas_tree:",
+		"Ho ho ho! You are on the nice list! :santa:",
+		"Grinch isn't the only one to steal Christmas :grinch:",
+		"Yippee-ki-yay Mother Hubbard! :diehardxmas:",
+	}, reply)
+
+	// Any other day the first player is an early bird
+	reply = getWordleReply(result, []Result{result}, christmas.AddDate(0, 0, 1))
+	assert.NotContains(t, reply, "Christmas")
+	assert.NotEmpty(t, reply)
+}
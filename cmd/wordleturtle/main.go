@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 	"wordleturtle/app"
 	"wordleturtle/config"
 )
@@ -12,6 +13,19 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(c, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "repl" {
+		if err := repl(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	handler := app.NewHandler(c)
 	log.Fatal(handler.Start())
 }
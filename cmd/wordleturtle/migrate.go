@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"wordleturtle/app"
+	"wordleturtle/config"
+)
+
+// migrate implements `wordleturtle migrate [-dry-run] [status]`
+//
+// status prints the current schema version and the pending migrations,
+// -dry-run also prints the SQL that would run, and with neither the
+// pending migrations are applied.
+func migrate(c *config.BotConfig, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "print the pending migrations without applying them")
+	flags.Parse(args)
+
+	if *dryRun || flags.Arg(0) == "status" {
+		status, err := app.GetMigrationStatus(c)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Schema version %d, %d pending\n", status.Current, len(status.Pending))
+		for _, m := range status.Pending {
+			fmt.Printf("  %04d_%s\n", m.Version, m.Name)
+			if *dryRun {
+				fmt.Println(m.SQL)
+			}
+		}
+		return nil
+	}
+
+	applied, err := app.Migrate(c)
+	for _, m := range applied {
+		fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("Already up to date")
+	}
+	return nil
+}
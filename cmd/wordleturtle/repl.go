@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"wordleturtle/app"
+)
+
+// repl implements `wordleturtle repl [-db path] [-v]`
+//
+// Results are kept in a throwaway SQLite database unless -db names one, and
+// the bot's logging is hidden unless -v is given.
+func repl(args []string) error {
+	flags := flag.NewFlagSet("repl", flag.ExitOnError)
+	path := flags.String("db", "", "SQLite file to keep results in, instead of a temporary one")
+	verbose := flags.Bool("v", false, "show the bot's logging")
+	flags.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *path == "" {
+		dir, err := os.MkdirTemp("", "wordleturtle")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		*path = filepath.Join(dir, "wordles")
+	}
+	db, err := app.NewSQLiteDB(*path, app.MigrationParams{})
+	if err != nil {
+		return err
+	}
+	return app.NewREPL(db, os.Stdout, app.NowDefault()).Run(os.Stdin)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -18,4 +19,19 @@ func TestParse(t *testing.T) {
 	assert.Equal(t, "i guess", c.WelcomeMessage)
 	assert.Equal(t, EnvDevelopment, c.Env)
 	assert.Equal(t, ":12022", c.BindAddr)
-}
\ No newline at end of file
+	assert.Equal(t, time.Minute, c.TickInterval)
+	assert.Equal(t, DBSQLite, c.DBDriver)
+	assert.Equal(t, 10*time.Minute, c.MembersCacheTTL)
+}
+
+func TestParse_WorkURLNeedsTickSecret(t *testing.T) {
+	os.Setenv("WORK_URL", "https://example.com/work")
+	defer os.Unsetenv("WORK_URL")
+	_, err := Parse()
+	assert.Error(t, err)
+
+	os.Setenv("TICK_SECRET", "ticksecret")
+	defer os.Unsetenv("TICK_SECRET")
+	_, err = Parse()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,14 @@
+// Package database holds the schema migrations. They are embedded in the
+// binary so the bot can bring its own database up to date when it starts.
+//
+// Each migration is a file named <version>_<name>.sql, applied in version
+// order, once. Never edit a migration that has shipped; add a new one.
+package database
+
+import "embed"
+
+// Migrations holds a directory of migrations for each supported database.
+// The same version number makes the same change in every directory.
+//
+//go:embed sqlite/*.sql postgres/*.sql
+var Migrations embed.FS
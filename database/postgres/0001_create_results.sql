@@ -0,0 +1,9 @@
+CREATE TABLE results (
+    wordlenum INTEGER,
+    userId VARCHAR(64),
+    displayName VARCHAR(64),
+    score INTEGER,
+    hardmode INTEGER,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wordlenum, userId)
+);
@@ -0,0 +1,5 @@
+-- Results from before other games were tracked are all Wordles
+ALTER TABLE results ADD COLUMN game VARCHAR(32) DEFAULT 'Wordle';
+UPDATE results SET game='Wordle';
+ALTER TABLE results DROP CONSTRAINT results_pkey;
+ALTER TABLE results ADD PRIMARY KEY (game, wordlenum, userId);
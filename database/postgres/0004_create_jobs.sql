@@ -0,0 +1,11 @@
+CREATE TABLE jobs (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(32),
+    game VARCHAR(32),
+    wordlenum INTEGER,
+    channel VARCHAR(64),
+    -- unix seconds
+    runAt BIGINT,
+    claimed INTEGER DEFAULT 0,
+    CONSTRAINT jobs_unique UNIQUE (kind, game, wordlenum, channel)
+);
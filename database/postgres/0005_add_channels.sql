@@ -0,0 +1,13 @@
+-- Everything recorded before channels were tracked belongs to the one channel
+-- the bot was running in, set by LEGACY_TEAM and LEGACY_CHANNEL
+ALTER TABLE results ADD COLUMN team VARCHAR(64), ADD COLUMN channel VARCHAR(64);
+UPDATE results SET
+    team=(SELECT value FROM migration_params WHERE name='legacy_team'),
+    channel=(SELECT value FROM migration_params WHERE name='legacy_channel');
+ALTER TABLE results DROP CONSTRAINT results_pkey;
+ALTER TABLE results ADD PRIMARY KEY (team, channel, game, wordlenum, userId);
+
+ALTER TABLE jobs ADD COLUMN team VARCHAR(64);
+UPDATE jobs SET team=(SELECT value FROM migration_params WHERE name='legacy_team');
+ALTER TABLE jobs DROP CONSTRAINT jobs_unique;
+ALTER TABLE jobs ADD CONSTRAINT jobs_unique UNIQUE (kind, team, channel, game, wordlenum);
@@ -0,0 +1,12 @@
+CREATE TABLE ratings (
+    team VARCHAR(64),
+    channel VARCHAR(64),
+    game VARCHAR(32),
+    userId VARCHAR(64),
+    rating DOUBLE PRECISION,
+    rd DOUBLE PRECISION,
+    volatility DOUBLE PRECISION,
+    -- the last puzzle this rating was updated for
+    wordlenum INTEGER,
+    PRIMARY KEY (team, channel, game, userId)
+);
@@ -0,0 +1,9 @@
+-- The live summary message for each puzzle, which is edited as results come in
+CREATE TABLE summaries (
+    team VARCHAR(64),
+    channel VARCHAR(64),
+    game VARCHAR(32),
+    wordlenum INTEGER,
+    ts VARCHAR(32),
+    PRIMARY KEY (team, channel, game, wordlenum)
+);
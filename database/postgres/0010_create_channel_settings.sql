@@ -0,0 +1,12 @@
+-- Each channel's deadline, reminders and timezone. Channels without a row
+-- use the defaults.
+CREATE TABLE channel_settings (
+    team VARCHAR(64),
+    channel VARCHAR(64),
+    timezone VARCHAR(64),
+    -- minutes after midnight
+    deadline INTEGER,
+    -- comma separated minutes before the deadline
+    reminders VARCHAR(64),
+    PRIMARY KEY (team, channel)
+);
@@ -0,0 +1,19 @@
+-- Everyone's rating after each puzzle it was updated for, so ratings can be
+-- redone from a day whose results an admin changed
+CREATE TABLE rating_history (
+    team VARCHAR(64),
+    channel VARCHAR(64),
+    game VARCHAR(32),
+    userId VARCHAR(64),
+    wordlenum INTEGER,
+    rating DOUBLE PRECISION,
+    rd DOUBLE PRECISION,
+    volatility DOUBLE PRECISION,
+    -- 1 for ratings copied from before history was kept, which can't be
+    -- redone
+    seeded INTEGER DEFAULT 0,
+    PRIMARY KEY (team, channel, game, userId, wordlenum)
+);
+
+INSERT INTO rating_history (team, channel, game, userId, wordlenum, rating, rd, volatility, seeded)
+    SELECT team, channel, game, userId, wordlenum, rating, rd, volatility, 1 FROM ratings;
@@ -6,4 +6,4 @@ CREATE TABLE `results` (
     `hardmode` INTEGER,
     `timestamp` DATETIME DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (wordlenum, userId)
-);
\ No newline at end of file
+);
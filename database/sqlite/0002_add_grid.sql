@@ -0,0 +1 @@
+ALTER TABLE `results` ADD COLUMN `grid` TEXT;
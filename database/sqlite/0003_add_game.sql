@@ -0,0 +1,17 @@
+-- Results from before other games were tracked are all Wordles. SQLite can't
+-- change a primary key in place, so the table is rebuilt.
+CREATE TABLE `results_new` (
+    `game` VARCHAR(32) DEFAULT 'Wordle',
+    `wordlenum` INTEGER,
+    `userId` VARCHAR(64),
+    `displayName` VARCHAR(64),
+    `score` INTEGER,
+    `hardmode` INTEGER,
+    `grid` TEXT,
+    `timestamp` DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (game, wordlenum, userId)
+);
+INSERT INTO `results_new` (game, wordlenum, userId, displayName, score, hardmode, grid, timestamp)
+    SELECT 'Wordle', wordlenum, userId, displayName, score, hardmode, grid, timestamp FROM `results`;
+DROP TABLE `results`;
+ALTER TABLE `results_new` RENAME TO `results`;
@@ -0,0 +1,11 @@
+CREATE TABLE `jobs` (
+    `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `kind` VARCHAR(32),
+    `game` VARCHAR(32),
+    `wordlenum` INTEGER,
+    `channel` VARCHAR(64),
+    -- unix seconds
+    `runAt` INTEGER,
+    `claimed` INTEGER DEFAULT 0,
+    UNIQUE (kind, game, wordlenum, channel)
+);
@@ -0,0 +1,37 @@
+-- Everything recorded before channels were tracked belongs to the one channel
+-- the bot was running in, set by LEGACY_TEAM and LEGACY_CHANNEL
+CREATE TABLE `results_new` (
+    `team` VARCHAR(64),
+    `channel` VARCHAR(64),
+    `game` VARCHAR(32) DEFAULT 'Wordle',
+    `wordlenum` INTEGER,
+    `userId` VARCHAR(64),
+    `displayName` VARCHAR(64),
+    `score` INTEGER,
+    `hardmode` INTEGER,
+    `grid` TEXT,
+    `timestamp` DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team, channel, game, wordlenum, userId)
+);
+INSERT INTO `results_new` (team, channel, game, wordlenum, userId, displayName, score, hardmode, grid, timestamp)
+    SELECT (SELECT value FROM migration_params WHERE name='legacy_team'), (SELECT value FROM migration_params WHERE name='legacy_channel'),
+        game, wordlenum, userId, displayName, score, hardmode, grid, timestamp FROM `results`;
+DROP TABLE `results`;
+ALTER TABLE `results_new` RENAME TO `results`;
+
+CREATE TABLE `jobs_new` (
+    `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `kind` VARCHAR(32),
+    `team` VARCHAR(64),
+    `channel` VARCHAR(64),
+    `game` VARCHAR(32),
+    `wordlenum` INTEGER,
+    -- unix seconds
+    `runAt` INTEGER,
+    `claimed` INTEGER DEFAULT 0,
+    UNIQUE (kind, team, channel, game, wordlenum)
+);
+INSERT INTO `jobs_new` (id, kind, team, channel, game, wordlenum, runAt, claimed)
+    SELECT id, kind, (SELECT value FROM migration_params WHERE name='legacy_team'), channel, game, wordlenum, runAt, claimed FROM `jobs`;
+DROP TABLE `jobs`;
+ALTER TABLE `jobs_new` RENAME TO `jobs`;
@@ -0,0 +1,2 @@
+-- The ts of the Slack message a result was posted in, so edits and deletes can find it
+ALTER TABLE `results` ADD COLUMN `ts` VARCHAR(32);
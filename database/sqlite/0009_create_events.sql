@@ -0,0 +1,9 @@
+-- Slack events we've accepted, both to spot retries and as a queue to
+-- process them after acking
+CREATE TABLE `events` (
+    `id` VARCHAR(64) PRIMARY KEY,
+    `body` TEXT,
+    -- unix seconds
+    `receivedAt` INTEGER,
+    `claimed` INTEGER DEFAULT 0
+);
@@ -0,0 +1,18 @@
+-- How far either side of the channel's day a puzzle number is accepted
+ALTER TABLE `channel_settings` ADD COLUMN `grace` INTEGER DEFAULT 180;
+
+-- Results we refused to count, for admins to review
+CREATE TABLE `rejections` (
+    `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `team` VARCHAR(64),
+    `channel` VARCHAR(64),
+    `game` VARCHAR(32),
+    `wordlenum` INTEGER,
+    `userId` VARCHAR(64),
+    `displayName` VARCHAR(64),
+    `score` INTEGER,
+    `ts` VARCHAR(32),
+    `reason` TEXT,
+    -- unix seconds
+    `rejectedAt` INTEGER
+);
@@ -0,0 +1,25 @@
+-- People who can run admin commands in a channel, besides the platform's
+-- admins and ADMINS
+CREATE TABLE `channel_admins` (
+    `team` VARCHAR(64),
+    `channel` VARCHAR(64),
+    `userId` VARCHAR(64),
+    PRIMARY KEY (team, channel, userId)
+);
+
+-- Every change an admin made to results
+CREATE TABLE `audit_log` (
+    `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `team` VARCHAR(64),
+    `channel` VARCHAR(64),
+    `adminId` VARCHAR(64),
+    `action` VARCHAR(32),
+    `game` VARCHAR(32),
+    `wordlenum` INTEGER,
+    `userId` VARCHAR(64),
+    -- NULL when there was no result before, or isn't one after
+    `oldScore` INTEGER,
+    `newScore` INTEGER,
+    -- unix seconds
+    `changedAt` INTEGER
+);
@@ -0,0 +1,12 @@
+-- Players who aren't expected to play every day. Everyone else in the
+-- channel is active.
+CREATE TABLE `participation` (
+    `team` VARCHAR(64),
+    `channel` VARCHAR(64),
+    `userId` VARCHAR(64),
+    -- vacation, spectator or optedout
+    `state` VARCHAR(16),
+    -- the last day of a vacation, as YYYY-MM-DD
+    `awayUntil` VARCHAR(10) DEFAULT '',
+    PRIMARY KEY (team, channel, userId)
+);
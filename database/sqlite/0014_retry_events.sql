@@ -0,0 +1,7 @@
+-- Events stay queued until they're processed successfully. claimed is
+-- superseded by claimedAt, which lets another worker retry an event whose
+-- worker died, and done.
+ALTER TABLE `events` ADD COLUMN `claimedAt` INTEGER DEFAULT 0;
+ALTER TABLE `events` ADD COLUMN `attempts` INTEGER DEFAULT 0;
+ALTER TABLE `events` ADD COLUMN `done` INTEGER DEFAULT 0;
+UPDATE `events` SET `done`=`claimed`;
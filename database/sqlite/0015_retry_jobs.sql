@@ -0,0 +1,7 @@
+-- Jobs stay scheduled until they've run successfully. claimed is superseded
+-- by claimedAt, which lets another tick retry a job whose worker died, and
+-- done.
+ALTER TABLE `jobs` ADD COLUMN `claimedAt` INTEGER DEFAULT 0;
+ALTER TABLE `jobs` ADD COLUMN `attempts` INTEGER DEFAULT 0;
+ALTER TABLE `jobs` ADD COLUMN `done` INTEGER DEFAULT 0;
+UPDATE `jobs` SET `done`=`claimed`;
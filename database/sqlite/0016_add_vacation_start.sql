@@ -0,0 +1,2 @@
+-- The first day of a vacation, as YYYY-MM-DD, so days before it still count
+ALTER TABLE `participation` ADD COLUMN `awayFrom` VARCHAR(10) DEFAULT '';